@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An index of the generators found in several generator directories, see BuildCatalog.
+//
+// Set Request.Catalog to reference generators by their qualified name in a render spec.
+type Catalog struct {
+	// All generators found, sorted by qualified name.
+	Entries []CatalogEntry `yaml:"entries"`
+}
+
+type CatalogEntry struct {
+	// The name of the directory that was scanned, followed by the path of the generator directory below it,
+	// and the generator name, e.g. 'backend-generators/go/service'. Unique within the catalog.
+	//
+	// If several scanned directories have the same name, the names of their parent directories are prepended
+	// until they differ, e.g. 'team-a/generators/service' and 'team-b/generators/service'.
+	QualifiedName string `yaml:"qualified_name"`
+
+	// The generator directory that contains the generator spec.
+	SourceBaseDir string `yaml:"sourcedir"`
+
+	// The name and metadata of the generator.
+	Info GeneratorInfo `yaml:"info"`
+}
+
+// Find returns the entry for a qualified name, or for a plain generator name if only one generator has that name.
+func (c *Catalog) Find(name string) (*CatalogEntry, error) {
+	matches := []string{}
+	var found *CatalogEntry
+	for n := range c.Entries {
+		if c.Entries[n].QualifiedName == name {
+			return &c.Entries[n], nil
+		}
+		if c.Entries[n].Info.Name == name {
+			matches = append(matches, c.Entries[n].QualifiedName)
+			found = &c.Entries[n]
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("generator %s not found in catalog", name)
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("generator name %s is ambiguous, use one of the qualified names %s", name, strings.Join(matches, ", "))
+	}
+	return found, nil
+}
@@ -6,11 +6,82 @@ package api
 //
 // The values of the variables as well as what generator to use come from a RenderSpec instead.
 type GeneratorSpec struct {
+	// Optional metadata, presented by ListGenerators. Not used during rendering.
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Tags        []string `yaml:"tags"`
+	Owners      []string `yaml:"owners"`
+	DocsURL     string   `yaml:"docs_url"`
+
+	// Optional minimum version of this library needed to use the generator, e.g. '1.6.0'.
+	//
+	// Generators that need a newer library version are still listed by ListGenerators, but all other
+	// operations refuse to use them.
+	MinLibraryVersion string `yaml:"min_library_version"`
+
+	// Optional name of another generator that this generator is based on.
+	//
+	// Variables are merged field by field, so you only need to list what you want to change, but fields of the base
+	// variable cannot be cleared. Templates replace
+	// templates of the base generator with the same target, all other templates are added after those of the
+	// base generator. The remaining settings of this generator take precedence if they are set, and hooks,
+	// includes and extension lists are appended to those of the base generator. The base generator must not
+	// have migrations.
+	Extends string `yaml:"extends"`
+
+	// Optional directory to find the base generator in, relative to the directory of this generator.
+	// Defaults to the same directory. The templates and includes of the base generator stay relative to
+	// its own directory.
+	ExtendsSourceDir string `yaml:"extends_source"`
+
+	// Optional semantic version of the generator, e.g. '1.4.0'.
+	//
+	// It is recorded in render spec files written by this library. Increase the major version whenever existing
+	// render specs need changes to keep working, so Render can detect render specs written by an older version.
+	Version string `yaml:"version"`
+
 	// The list of templates to render (if their condition evaluates to true)
 	Templates []TemplateSpec `yaml:"templates"`
 
 	// The list of available variables
 	Variables map[string]VariableSpec `yaml:"variables"`
+
+	// The names of the variables in the order they are declared in the generator spec file, so frontends can present
+	// them in a logical order. Filled in when the generator spec is read.
+	VariableOrder []string `yaml:"-"`
+
+	// Optional custom delimiters used when parsing the template files, e.g. '[[' and ']]'. Leave empty for '{{' and '}}'.
+	//
+	// Useful if the files you wish to template themselves contain double braces (helm charts, github workflows, ...).
+	// Can be overridden for individual templates. Note that this does not affect the template expressions in
+	// the generator spec itself (source, target, condition, ...), those always use the default delimiters.
+	LeftDelimiter  string `yaml:"left_delimiter"`
+	RightDelimiter string `yaml:"right_delimiter"`
+
+	// Optional formatter applied to all rendered files, can be overridden for individual templates.
+	//
+	// One of 'go', 'json', 'yaml', 'auto' (select by target file extension), or 'none' (the default).
+	Format string `yaml:"format"`
+
+	// Commands to run before any files are rendered. Only executed if the caller sets Request.ExecuteHooks.
+	PreRenderHooks []HookSpec `yaml:"pre_render_hooks"`
+
+	// Commands to run after all files were rendered successfully. Only executed if the caller sets Request.ExecuteHooks.
+	PostRenderHooks []HookSpec `yaml:"post_render_hooks"`
+
+	// File extensions (e.g. '.png') of templates that are always copied verbatim, as if just_copy was set.
+	//
+	// Files with other extensions are copied verbatim if their contents look binary (contain NUL bytes).
+	BinaryExtensions []string `yaml:"binary_extensions"`
+
+	// File extensions of templates that are always rendered, even if their contents look binary.
+	TextExtensions []string `yaml:"text_extensions"`
+
+	// Other generators to render as part of this one, after this generator's own templates.
+	Includes []IncludeSpec `yaml:"includes"`
+
+	// Changes to apply to render specs written for older versions of this generator, see MigrateRenderSpec.
+	Migrations []MigrationSpec `yaml:"migrations"`
 }
 
 // Specifies a template to process, or a list to iterate over, if WithItems is nonempty (setting {{ item }} each run)
@@ -18,13 +89,80 @@ type GeneratorSpec struct {
 // Every field is evaluated as a template itself, so you can use variables in all fields.
 //
 // If Condition is set and evaluates to one of 'false', '0', 'no', the render run is skipped
+//
+// LeftDelimiter and RightDelimiter override the template delimiters set in the GeneratorSpec for this template only.
+//
+// WithFiles patterns may contain '**' to match any number of directories, and patterns starting with '!' exclude files.
+// Hidden files and directories are skipped by '**' unless IncludeHidden is set or the pattern names them explicitly.
+//
+// If Directory is set, every file below that directory is processed, and RelativeTargetPath is the directory the
+// files are placed in (leave empty for the target root). Each path segment of the files may contain template
+// expressions. Files ending in .tmpl are rendered with that suffix removed, all other files are copied verbatim.
+//
+// Format selects a formatter for the rendered output, overriding the Format set in the GeneratorSpec.
+//
+// Mode sets the file permissions of the target file as an octal string, e.g. '0755'. If InheritMode is true instead,
+// the target file gets the permissions of the source template. If neither is set, new files are written with 0644
+// and existing files keep their permissions.
+//
+// Two templates, or two iterations of a template, must not render the same target path, unless the later one sets
+// AllowOverride. Then the later one replaces the earlier one, which is not written at all.
 type TemplateSpec struct {
 	RelativeSourcePath string        `yaml:"source"`
 	RelativeTargetPath string        `yaml:"target"`
 	Condition          string        `yaml:"condition"`
 	WithItems          []interface{} `yaml:"with_items"`
 	WithFiles          []string      `yaml:"with_files"`
+	IncludeHidden      bool          `yaml:"include_hidden"`
+	Directory          string        `yaml:"directory"`
 	JustCopy           bool          `yaml:"just_copy"`
+	LeftDelimiter      string        `yaml:"left_delimiter"`
+	RightDelimiter     string        `yaml:"right_delimiter"`
+	Mode               string        `yaml:"mode"`
+	InheritMode        bool          `yaml:"inherit_mode"`
+	Format             string        `yaml:"format"`
+	AllowOverride      bool          `yaml:"allow_override"`
+
+	// The directory the source paths of this template are relative to, relative to the directory of the generator.
+	// Set when the template is inherited from a base generator in another directory, empty otherwise.
+	SourceDir string `yaml:"-"`
+}
+
+// Specifies a command to run before or after rendering, such as 'go mod tidy' or 'git init'.
+//
+// Every field is evaluated as a template, so you can use variables in all fields.
+//
+// If Condition is set and evaluates to one of 'false', '0', 'no', 'skip', the hook is not run.
+type HookSpec struct {
+	// The command and its arguments. No shell is involved, use something like ['sh', '-c', '...'] if you need one.
+	Command []string `yaml:"command"`
+
+	// The directory to run the command in, relative to the target directory. Defaults to the target directory.
+	WorkingDir string `yaml:"workdir"`
+
+	Condition string `yaml:"condition"`
+}
+
+// Specifies another generator to render as part of this one.
+//
+// Condition and all string values in Parameters are evaluated as templates using the parameters of the including
+// generator, so you can pass on values or derive new ones. If Condition evaluates to one of 'false', '0', 'no',
+// 'skip', the generator is not included.
+type IncludeSpec struct {
+	// Name of the generator to include (determines yaml file to read for generator spec).
+	Generator string `yaml:"generator"`
+
+	// Optional directory to find the generator in, relative to the directory of the including generator.
+	// Defaults to the same directory.
+	SourceDir string `yaml:"source"`
+
+	// Values for the variables of the included generator. Variables not listed here get their defaults.
+	Parameters map[string]interface{} `yaml:"parameters"`
+
+	Condition string `yaml:"condition"`
+
+	// Optional subdirectory of the target directory to render the included generator into.
+	TargetDir string `yaml:"target"`
 }
 
 // Specifies a variable that this generator uses, so it is made available in the templates.
@@ -39,4 +177,82 @@ type VariableSpec struct {
 
 	// Default value. If missing, the variable is considered required. Note that variables can have structured content.
 	DefaultValue interface{} `yaml:"default"`
+
+	// The following fields are only used to present the variable to users, e.g. in an interactive frontend.
+
+	// Short name to show instead of the variable name, e.g. 'Service Name'.
+	Label string `yaml:"label"`
+
+	// Name of the group or section to show the variable in, e.g. 'Database'.
+	Group string `yaml:"group"`
+
+	// Example value to show as a placeholder. Unlike DefaultValue, this is never used as a value.
+	Example string `yaml:"example"`
+
+	// Set for variables that most users can leave at their default value, so frontends can hide them.
+	Advanced bool `yaml:"advanced"`
+
+	// Longer help text, in addition to the Description.
+	Help string `yaml:"help"`
+}
+
+// Specifies how to update render specs written for a version before Version, so they keep working with Version.
+//
+// MigrateRenderSpec applies all migrations for versions after the one recorded in the render spec, up to the
+// current version of the generator, in order of their versions. Render specs that do not record a version
+// get all migrations.
+type MigrationSpec struct {
+	// The generator version that introduced the changes, e.g. '2.0.0'. Required.
+	Version string `yaml:"version"`
+
+	// The changes to make, in order.
+	Steps []MigrationStep `yaml:"steps"`
+}
+
+// A single change to the parameters of a render spec. Set exactly one of Rename, Move, SetDefault, Drop, Transform.
+//
+// All steps do nothing if the parameter they refer to is not present in the render spec, except for SetDefault,
+// which does nothing if it is.
+type MigrationStep struct {
+	// Rename the parameter to To.
+	Rename string `yaml:"rename"`
+
+	// Move the parameter into a map parameter. To is the path to move it to, separated by dots, e.g. 'database.name'.
+	// Missing maps are created.
+	Move string `yaml:"move"`
+
+	// Add the parameter with Value.
+	SetDefault string `yaml:"set_default"`
+
+	// Remove the parameter.
+	Drop string `yaml:"drop"`
+
+	// Replace the value of the parameter with the result of Template, which is evaluated with all parameters
+	// of the render spec, e.g. '{{ .serviceName | lower }}'.
+	Transform string `yaml:"transform"`
+
+	To       string      `yaml:"to"`
+	Value    interface{} `yaml:"value"`
+	Template string      `yaml:"template"`
+}
+
+// Describes a generator found by ListGenerators.
+type GeneratorInfo struct {
+	// The name of the generator, as used in render specs.
+	Name string `yaml:"name"`
+
+	// The metadata from the generator spec.
+	Version           string   `yaml:"version"`
+	Title             string   `yaml:"title"`
+	Description       string   `yaml:"description"`
+	Tags              []string `yaml:"tags"`
+	Owners            []string `yaml:"owners"`
+	DocsURL           string   `yaml:"docs_url"`
+	MinLibraryVersion string   `yaml:"min_library_version"`
+
+	// False if the generator needs a newer version of this library, or if its spec could not be read.
+	Compatible bool `yaml:"compatible"`
+
+	// Why the generator spec could not be read, empty if it is valid. The metadata is then left empty.
+	Error string `yaml:"error,omitempty"`
 }
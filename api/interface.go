@@ -7,6 +7,13 @@ type Api interface {
 	// Obtain the list of available generator names by looking for generator-*.yaml files in sourceBaseDir
 	FindGeneratorNames(ctx context.Context, sourceBaseDir string) ([]string, error)
 
+	// Obtain the available generators in sourceBaseDir with their metadata, sorted by name
+	ListGenerators(ctx context.Context, sourceBaseDir string) ([]GeneratorInfo, error)
+
+	// Scan the baseDirs and all their subdirectories for generators, so they can be referenced by qualified
+	// name using Request.Catalog
+	BuildCatalog(ctx context.Context, baseDirs []string) (*Catalog, error)
+
 	// Obtain a specific generator spec, read from "generator-<generatorName>.yaml" in sourceBaseDir
 	ObtainGeneratorSpec(ctx context.Context, sourceBaseDir string, generatorName string) (*GeneratorSpec, error)
 
@@ -35,7 +42,33 @@ type Api interface {
 	//
 	// If you leave request.RenderSpecFile empty, it defaults to "generated-main.yaml"
 	//
+	// The RenderSpec file itself is never written.
+	//
 	// Warning: existing files are silently overwritten! The idea is that you keep both your
 	// generators and the generator targets in source control, so you can then review the changes made.
 	Render(ctx context.Context, request *Request) *Response
+
+	// Update the RenderSpec in <request.TargetBaseDir>/<request.RenderSpecFile> to the current version of its generator.
+	//
+	// Applies the migrations of the generator for all versions after the one recorded in the RenderSpec, records the
+	// current version, and writes the file in place. The response lists the changes made, and reports the file as
+	// updated or unchanged. If the parameters are still invalid after migrating, nothing is written.
+	//
+	// If you leave request.RenderSpecFile empty, it defaults to "generated-main.yaml". Set request.DryRun to
+	// only find out what would change.
+	MigrateRenderSpec(ctx context.Context, request *Request) *Response
+
+	// Re-render a target directory with the current version of its generator.
+	//
+	// Reads the RenderSpec just like Render, applies migrations just like MigrateRenderSpec, and sets all new
+	// variables that have a default value to it. If any new variables need a value from the user, they are listed
+	// in the response, and nothing is rendered. Otherwise, the files are rendered, files that the previous Upgrade
+	// wrote but that are no longer rendered are deleted and reported with action "deleted", and the
+	// updated RenderSpec is written, recording the rendered files for the next Upgrade.
+	//
+	// Files can only be deleted if they are recorded in the RenderSpec. Only Upgrade records them, so the first
+	// Upgrade of a target directory never deletes anything.
+	//
+	// Set request.DryRun to only find out what would happen.
+	Upgrade(ctx context.Context, request *Request) *Response
 }
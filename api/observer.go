@@ -0,0 +1,62 @@
+package api
+
+import "context"
+
+// A RenderObserver is notified about the progress of a Render call, e.g. to draw a progress bar.
+//
+// Set it in Request.Observer. Callbacks are never made concurrently, but they are made synchronously,
+// so a slow observer slows down rendering.
+//
+// OnError is called for every error concerning an individual file or hook. Errors that prevent rendering altogether,
+// such as a missing render spec, are only reported in the Response passed to OnRenderFinished.
+//
+// Embed NoopRenderObserver if you only want to implement some of the callbacks.
+type RenderObserver interface {
+	// Called once the generator spec has been read, before any hooks are run or templates are rendered.
+	OnRenderStart(ctx context.Context, progress RenderProgress)
+
+	// Called before each entry in the templates list of the generator spec is processed.
+	OnTemplateStart(ctx context.Context, tplSpec TemplateSpec, progress RenderProgress)
+
+	// Called after a file has been written successfully.
+	OnFileRendered(ctx context.Context, result FileResult, progress RenderProgress)
+
+	// Called if a file is not written because its condition evaluated to false, or because a later template
+	// renders the same target path with allow_override. In the latter case, the file is also passed to
+	// OnFileRendered (or OnError) for the later template, with the SourceTemplate of the later template.
+	OnFileSkipped(ctx context.Context, relativeFilePath string, progress RenderProgress)
+
+	// Called for each error.
+	OnError(ctx context.Context, err error, progress RenderProgress)
+
+	// Called exactly once at the end of every Render call, even if it failed before OnRenderStart.
+	OnRenderFinished(ctx context.Context, response *Response, progress RenderProgress)
+}
+
+// Counts passed to every RenderObserver callback.
+type RenderProgress struct {
+	// Number of entries in the templates list of the generator spec.
+	TemplatesTotal int
+
+	// Number of entries in the templates list that have been started so far, including the current one.
+	TemplatesStarted int
+
+	FilesRendered int
+	FilesSkipped  int
+	Errors        int
+}
+
+// NoopRenderObserver implements all RenderObserver callbacks by doing nothing.
+type NoopRenderObserver struct{}
+
+func (o NoopRenderObserver) OnRenderStart(_ context.Context, _ RenderProgress) {}
+
+func (o NoopRenderObserver) OnTemplateStart(_ context.Context, _ TemplateSpec, _ RenderProgress) {}
+
+func (o NoopRenderObserver) OnFileRendered(_ context.Context, _ FileResult, _ RenderProgress) {}
+
+func (o NoopRenderObserver) OnFileSkipped(_ context.Context, _ string, _ RenderProgress) {}
+
+func (o NoopRenderObserver) OnError(_ context.Context, _ error, _ RenderProgress) {}
+
+func (o NoopRenderObserver) OnRenderFinished(_ context.Context, _ *Response, _ RenderProgress) {}
@@ -0,0 +1,24 @@
+package api
+
+import "context"
+
+// A PostProcessor is given the contents of each rendered file before it is written, and may modify them.
+//
+// Add post processors to Request.PostProcessors for a render run. They form a chain, each one receiving the output
+// of the previous one, after any formatter configured in the generator spec has run. Files that are copied
+// verbatim (just_copy or binary files) are not passed to post processors.
+//
+// If a post processor returns an error, the file is not written and the error is reported for it.
+//
+// If Request.Parallelism is greater than 1, Process may be called for several files at the same time, so
+// implementations must be safe for concurrent use.
+type PostProcessor interface {
+	Process(ctx context.Context, targetPath string, content []byte) ([]byte, error)
+}
+
+// PostProcessorFunc allows using an ordinary function as a PostProcessor.
+type PostProcessorFunc func(ctx context.Context, targetPath string, content []byte) ([]byte, error)
+
+func (f PostProcessorFunc) Process(ctx context.Context, targetPath string, content []byte) ([]byte, error) {
+	return f(ctx, targetPath, content)
+}
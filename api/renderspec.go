@@ -10,7 +10,57 @@ type RenderSpec struct {
 	// generator-main.yaml, and GeneratorName should be set to "main".
 	GeneratorName string `yaml:"generator"`
 
+	// The version of the generator that this render spec was written for, if the generator spec declares one.
+	GeneratorVersion string `yaml:"generator_version,omitempty"`
+
 	// Assign variable "key" value "value". All values are evaluated as templates until they no longer change,
 	// so the value of one variable can refer to other variables, even if using their default values.
 	Parameters map[string]interface{} `yaml:"parameters"`
+
+	// Optional order in which to write the parameters, parameters not listed come last, sorted by name.
+	ParameterOrder []string `yaml:"-"`
+
+	// Optional comments to write above the parameters, keyed by parameter name, e.g. their descriptions.
+	//
+	// Parameters that are already in the render spec file keep the comments they have there instead.
+	ParameterComments map[string]string `yaml:"-"`
+
+	// Optional warnings to write next to the parameters, keyed by parameter name, e.g. for a required value
+	// that is still missing. Unlike other comments, warnings are not kept when the file is written again.
+	ParameterWarnings map[string]string `yaml:"-"`
+
+	// Alternatively, the list of generators to render, in order, each with its own parameters.
+	//
+	// Leave GeneratorName and Parameters empty if you use this.
+	Generators []GeneratorInvocation `yaml:"generators,omitempty"`
+
+	// The files written by the last Upgrade, so the next Upgrade can remove files that are no longer rendered.
+	//
+	// Maintained by Upgrade, do not edit.
+	RenderedFiles []string `yaml:"rendered_files,omitempty"`
+}
+
+// A single generator to render as part of a RenderSpec that lists several generators.
+type GeneratorInvocation struct {
+	// Name of the generator to use, just like RenderSpec.GeneratorName.
+	GeneratorName string `yaml:"generator"`
+
+	// The version of the generator, just like RenderSpec.GeneratorVersion.
+	GeneratorVersion string `yaml:"generator_version,omitempty"`
+
+	// The parameters for this generator, just like RenderSpec.Parameters.
+	Parameters map[string]interface{} `yaml:"parameters"`
+
+	// Optional order in which to write the parameters, just like RenderSpec.ParameterOrder.
+	ParameterOrder []string `yaml:"-"`
+
+	// Optional comments and warnings to write with the parameters, just like RenderSpec.ParameterComments
+	// and RenderSpec.ParameterWarnings.
+	ParameterComments map[string]string `yaml:"-"`
+	ParameterWarnings map[string]string `yaml:"-"`
+
+	// Optional subdirectory of the target directory to render the files of this generator into.
+	//
+	// Hooks of this generator also run relative to this directory.
+	TargetDir string `yaml:"target,omitempty"`
 }
@@ -10,6 +10,42 @@ type Request struct {
 
 	// yaml-file to read for RenderSpec, if not set, defaults to "generated-main.yaml".
 	RenderSpecFile string `yaml:"renderspec"`
+
+	// Run the pre and post render hooks declared in the generator spec (Render only). Hooks execute arbitrary
+	// commands, so they are never run unless you set this.
+	ExecuteHooks bool `yaml:"hooks"`
+
+	// Optional chain of post processors applied to every rendered file before it is written (Render only).
+	PostProcessors []PostProcessor `yaml:"-"`
+
+	// Evaluate and render everything, but do not write any files and do not run any hooks (Render only).
+	//
+	// Use this to find out which files would be written, and whether rendering would succeed.
+	DryRun bool `yaml:"dryrun"`
+
+	// Only write any files if all of them were rendered successfully (Render only).
+	//
+	// All rendered files are kept in memory, and only written at the end, each to a temporary file that is then
+	// renamed into place. If any file cannot be written, all files written so far are restored.
+	Transactional bool `yaml:"transactional"`
+
+	// Maximum number of files to render at the same time (Render only). 0 or 1 renders one file after the other.
+	//
+	// Rendered files are reported in the same order either way, but the order of observer events for
+	// rendered files is not deterministic when rendering in parallel.
+	Parallelism int `yaml:"parallelism"`
+
+	// Fail if the render spec was written for a different major version of the generator (Render only).
+	//
+	// If not set, rendering continues and the response contains a warning instead.
+	FailOnIncompatibleVersion bool `yaml:"fail_on_incompatible_version"`
+
+	// Optional catalog to look up the generators named in the render spec by their qualified or plain names,
+	// instead of reading them from SourceBaseDir.
+	Catalog *Catalog `yaml:"-"`
+
+	// Optional observer that is notified about the progress of rendering (Render only).
+	Observer RenderObserver `yaml:"-"`
 }
 
 // Information about the results of a render run
@@ -17,10 +53,74 @@ type Response struct {
 	Success       bool
 	RenderedFiles []FileResult
 	Errors        []error
+
+	// results of any hooks that were run, in order of execution
+	HookResults []HookResult
+
+	// problems that did not prevent rendering, such as an incompatible generator version
+	Warnings []string
+
+	// descriptions of the changes made to the render spec (MigrateRenderSpec and Upgrade only), in order
+	Changes []string
+
+	// variables that were missing from the render spec and were set to their default value (Upgrade only)
+	DefaultedParameters []string
+
+	// variables that are missing from the render spec and have no default value, so the user needs to
+	// provide a value (Upgrade only). Nothing is rendered if there are any.
+	MissingParameters []string
 }
 
 type FileResult struct {
 	Success          bool
 	RelativeFilePath string
 	Errors           []error
+
+	// true if the file was copied verbatim rather than rendered as a template (just_copy or binary file)
+	Copied bool
+
+	// what happened to the target file (Render and Upgrade only). Empty if rendering the file failed.
+	Action FileAction
+
+	// the template file the target file was rendered from, relative to the generator directory (Render only).
+	// Templates inherited through extends from another directory start with the path to that directory,
+	// e.g. '../base-generators/README.md.tmpl'. Templates of included generators are relative to their own directory.
+	SourceTemplate string
+	// the value of item for templates with with_items (Render only)
+	Item interface{}
+	// the value of file for templates with with_files or directory (Render only)
+	File string
+
+	// the number of bytes and the hex encoded sha256 hash of the rendered contents (Render only)
+	Size int
+	Hash string
+}
+
+type FileAction string
+
+const (
+	// the target file did not exist before
+	FileCreated FileAction = "created"
+	// the target file existed, but had different contents or permissions
+	FileUpdated FileAction = "updated"
+	// the target file already had exactly the rendered contents and permissions, so it was not written
+	FileUnchanged FileAction = "unchanged"
+	// the target file was not rendered, because the condition of its template was false, or a later template
+	// replaces it using allow_override
+	FileSkipped FileAction = "skipped"
+	// the target file was copied verbatim (just_copy or binary file), and created or updated
+	FileCopied FileAction = "copied"
+	// the target file was deleted, because the generator no longer renders it
+	FileDeleted FileAction = "deleted"
+)
+
+type HookResult struct {
+	Success    bool
+	Command    []string
+	WorkingDir string
+	// the exit code of the command, or -1 if it could not be run at all
+	ExitCode int
+	// combined stdout and stderr of the command
+	Output string
+	Errors []error
 }
@@ -0,0 +1,6 @@
+package api
+
+// The version of this library, compared against GeneratorSpec.MinLibraryVersion.
+//
+// Do not edit, the release process sets this to the version being released (see .releaserc.json).
+const LibraryVersion = "1.6.0"
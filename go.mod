@@ -4,7 +4,7 @@ go 1.14
 
 require (
 	github.com/Masterminds/goutils v1.1.1 // indirect
-	github.com/Masterminds/semver v1.5.0 // indirect
+	github.com/Masterminds/semver v1.5.0
 	github.com/Masterminds/sprig v2.22.0+incompatible
 	github.com/StephanHCB/go-autumn-logging v0.3.0
 	github.com/google/uuid v1.3.0 // indirect
@@ -14,4 +14,5 @@ require (
 	github.com/stretchr/testify v1.8.4
 	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519 // indirect
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,98 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"gopkg.in/yaml.v3"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	None = "none"
+	Auto = "auto"
+	Go   = "go"
+	Json = "json"
+	Yaml = "yaml"
+)
+
+// Format applies the formatter selected by name to the content of the file to be written to targetPath.
+//
+// An empty name means no formatting. Auto selects the formatter from the extension of targetPath, leaving
+// files with unknown extensions unchanged.
+func Format(name string, targetPath string, content []byte) ([]byte, error) {
+	if name == Auto {
+		name = byExtension(targetPath)
+	}
+
+	switch name {
+	case "", None:
+		return content, nil
+	case Go:
+		return format.Source(content)
+	case Json:
+		return formatJson(content)
+	case Yaml:
+		return formatYaml(content)
+	default:
+		return nil, fmt.Errorf("unknown formatter '%s'", name)
+	}
+}
+
+func byExtension(targetPath string) string {
+	switch strings.ToLower(path.Ext(targetPath)) {
+	case ".go":
+		return Go
+	case ".json":
+		return Json
+	case ".yaml", ".yml":
+		return Yaml
+	default:
+		return None
+	}
+}
+
+func formatJson(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(content), "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// formatYaml normalizes indentation, keeping key order and comments, and supports multiple documents.
+func formatYaml(content []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	documents := 0
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := encoder.Encode(&document); err != nil {
+			return nil, err
+		}
+		documents++
+	}
+	if documents == 0 {
+		// nothing but whitespace and comments
+		return content, nil
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,98 @@
+package implementation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// runHooks runs the hooks in order, stopping at the first one that fails.
+//
+// Hooks whose condition evaluates to false are not run, and are not reported in the results.
+func (i *GeneratorImpl) runHooks(ctx context.Context, run *renderRun, phase string, hooks []api.HookSpec, parameters map[string]interface{}) ([]api.HookResult, bool) {
+	results := []api.HookResult{}
+	for n, hook := range hooks {
+		templateName := fmt.Sprintf("__%s_hook_%d", strings.ReplaceAll(phase, " ", "_"), n+1)
+		condition, err := i.evaluateCondition(ctx, hook.Condition, parameters, templateName+"_condition")
+		if err != nil {
+			results = append(results, i.errorHookResult(ctx, hook.Command, hook.WorkingDir, fmt.Errorf("error evaluating condition from '%s' for %s hook #%d: %s", hook.Condition, phase, n+1, err)))
+			run.progress.failed(ctx, results[len(results)-1].Errors[0])
+			return results, false
+		}
+		if !condition {
+			continue
+		}
+
+		result := i.runHook(ctx, run, phase, n+1, &hook, parameters, templateName)
+		results = append(results, result)
+		if !result.Success {
+			run.progress.failed(ctx, result.Errors[0])
+			return results, false
+		}
+	}
+	return results, true
+}
+
+func (i *GeneratorImpl) runHook(ctx context.Context, run *renderRun, phase string, number int, hook *api.HookSpec, parameters map[string]interface{}, templateName string) api.HookResult {
+	if len(hook.Command) == 0 {
+		return i.errorHookResult(ctx, hook.Command, hook.WorkingDir, fmt.Errorf("%s hook #%d has no command", phase, number))
+	}
+
+	command := make([]string, len(hook.Command))
+	for n, arg := range hook.Command {
+		rendered, err := i.renderString(ctx, parameters, fmt.Sprintf("%s_command_%d", templateName, n+1), arg)
+		if err != nil {
+			return i.errorHookResult(ctx, hook.Command, hook.WorkingDir, fmt.Errorf("error evaluating command from '%s' for %s hook #%d: %s", arg, phase, number, err))
+		}
+		command[n] = rendered
+	}
+
+	workingDir, err := i.renderString(ctx, parameters, templateName+"_workdir", hook.WorkingDir)
+	if err != nil {
+		return i.errorHookResult(ctx, command, hook.WorkingDir, fmt.Errorf("error evaluating working directory from '%s' for %s hook #%d: %s", hook.WorkingDir, phase, number, err))
+	}
+	workingDir = path.Clean("./" + workingDir)
+	if workingDir == ".." || strings.HasPrefix(workingDir, "../") || path.IsAbs(workingDir) {
+		return i.errorHookResult(ctx, command, workingDir, fmt.Errorf("working directory %s for %s hook #%d is not inside the target directory - this is forbidden", workingDir, phase, number))
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	workingDir = path.Join(run.targetPrefix, workingDir)
+	cmd.Dir = path.Join(run.request.TargetBaseDir, workingDir)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err = cmd.Run()
+	result := api.HookResult{
+		Success:    err == nil,
+		Command:    command,
+		WorkingDir: workingDir,
+		Output:     output.String(),
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+		} else {
+			result.ExitCode = -1
+		}
+		result.Errors = []error{fmt.Errorf("%s hook #%d (%s) failed: %s", phase, number, strings.Join(command, " "), err)}
+	}
+	return result
+}
+
+func (i *GeneratorImpl) errorHookResult(_ context.Context, command []string, workingDir string, err error) api.HookResult {
+	return api.HookResult{
+		Success:    false,
+		Command:    command,
+		WorkingDir: workingDir,
+		ExitCode:   -1,
+		Errors:     []error{err},
+	}
+}
@@ -3,15 +3,23 @@ package implementation
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/Masterminds/semver"
 	"github.com/Masterminds/sprig"
 	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/internal/implementation/formatter"
 	"github.com/StephanHCB/go-generator-lib/internal/implementation/templatewrapper"
 	"github.com/StephanHCB/go-generator-lib/internal/repository/generatordir"
 	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -19,11 +27,39 @@ import (
 type GeneratorImpl struct {
 }
 
+// renderRun holds everything that stays the same while rendering a single generator during a Render call
+type renderRun struct {
+	request   *api.Request
+	genSpec   *api.GeneratorSpec
+	sourceDir *generatordir.GeneratorDirectory
+	targetDir *targetdir.TargetDirectory
+	progress  *progressTracker
+
+	// set in transactional mode, collects all files until they are written at the end
+	transaction *targetdir.Transaction
+
+	// set if the render spec lists several generators
+	invokedAs string
+
+	// set for generators included by the requested one, or rendered into a subdirectory
+	includedAs   string
+	targetPrefix string
+}
+
 func (i *GeneratorImpl) FindGeneratorNames(ctx context.Context, sourceBaseDir string) ([]string, error) {
 	sourceDir := generatordir.Instance(ctx, sourceBaseDir)
 	return sourceDir.FindGeneratorNames(ctx)
 }
 
+func (i *GeneratorImpl) ListGenerators(ctx context.Context, sourceBaseDir string) ([]api.GeneratorInfo, error) {
+	sourceDir := generatordir.Instance(ctx, sourceBaseDir)
+	return sourceDir.ListGenerators(ctx)
+}
+
+func (i *GeneratorImpl) BuildCatalog(ctx context.Context, baseDirs []string) (*api.Catalog, error) {
+	return generatordir.BuildCatalog(ctx, baseDirs)
+}
+
 func (i *GeneratorImpl) ObtainGeneratorSpec(ctx context.Context, sourceBaseDir string, generatorName string) (*api.GeneratorSpec, error) {
 	sourceDir := generatordir.Instance(ctx, sourceBaseDir)
 	return sourceDir.ObtainGeneratorSpec(ctx, generatorName)
@@ -33,7 +69,7 @@ func (i *GeneratorImpl) WriteRenderSpecWithDefaults(ctx context.Context, request
 	sourceDir := generatordir.Instance(ctx, request.SourceBaseDir)
 	targetDir := targetdir.Instance(ctx, request.TargetBaseDir)
 
-	genSpec, err := sourceDir.ObtainGeneratorSpec(ctx, generatorName)
+	genSpec, err := i.obtainGeneratorSpec(ctx, request, sourceDir, generatorName)
 	if err != nil {
 		return i.errorResponseToplevel(ctx, err)
 	}
@@ -59,7 +95,7 @@ func (i *GeneratorImpl) WriteRenderSpecWithValues(ctx context.Context, request *
 	sourceDir := generatordir.Instance(ctx, request.SourceBaseDir)
 	targetDir := targetdir.Instance(ctx, request.TargetBaseDir)
 
-	genSpec, err := sourceDir.ObtainGeneratorSpec(ctx, generatorName)
+	genSpec, err := i.obtainGeneratorSpec(ctx, request, sourceDir, generatorName)
 	if err != nil {
 		return i.errorResponseToplevel(ctx, err)
 	}
@@ -76,11 +112,8 @@ func (i *GeneratorImpl) WriteRenderSpecWithValues(ctx context.Context, request *
 		return i.errorResponseToplevel(ctx, err)
 	}
 
-	// check for extraneous parameters
-	for k := range parameters {
-		if _, ok := genSpec.Variables[k]; !ok {
-			return i.errorResponseToplevel(ctx, fmt.Errorf("parameter '%s' is not allowed according to generator spec", k))
-		}
+	if err := checkExtraneousParameters(genSpec, parameters); err != nil {
+		return i.errorResponseToplevel(ctx, err)
 	}
 
 	targetFile, err := targetDir.WriteRenderSpec(ctx, renderSpec, request.RenderSpecFile)
@@ -91,6 +124,15 @@ func (i *GeneratorImpl) WriteRenderSpecWithValues(ctx context.Context, request *
 }
 
 func (i *GeneratorImpl) Render(ctx context.Context, request *api.Request) *api.Response {
+	progress := newProgressTracker(request.Observer)
+	response := i.render(ctx, request, progress)
+	progress.finished(ctx, response)
+	return response
+}
+
+// helper functions
+
+func (i *GeneratorImpl) render(ctx context.Context, request *api.Request, progress *progressTracker) *api.Response {
 	sourceDir := generatordir.Instance(ctx, request.SourceBaseDir)
 	targetDir := targetdir.Instance(ctx, request.TargetBaseDir)
 
@@ -98,31 +140,222 @@ func (i *GeneratorImpl) Render(ctx context.Context, request *api.Request) *api.R
 	if err != nil {
 		return i.errorResponseToplevel(ctx, err)
 	}
+	return i.renderWithSpec(ctx, request, progress, sourceDir, targetDir, renderSpec)
+}
 
-	genSpec, err := sourceDir.ObtainGeneratorSpec(ctx, renderSpec.GeneratorName)
+func (i *GeneratorImpl) renderWithSpec(ctx context.Context, request *api.Request, progress *progressTracker,
+	sourceDir *generatordir.GeneratorDirectory, targetDir *targetdir.TargetDirectory, renderSpec *api.RenderSpec) *api.Response {
+	invocations, err := renderSpecInvocations(renderSpec)
 	if err != nil {
 		return i.errorResponseToplevel(ctx, err)
 	}
 
-	parameters, err := i.constructAndValidateParameterMap(ctx, genSpec, renderSpec)
-	if err != nil {
-		return i.errorResponseToplevel(ctx, err)
+	var transaction *targetdir.Transaction
+	if request.Transactional && !request.DryRun {
+		transaction = targetDir.BeginTransaction(ctx)
+	}
+
+	// the generators named in the render spec, and all generators taking part in the render run
+	requested := []*activeGenerator{}
+	generators := []*activeGenerator{}
+	warnings := []string{}
+	for n, invocation := range invocations {
+		run := &renderRun{
+			request:     request,
+			sourceDir:   sourceDir,
+			targetDir:   targetDir,
+			progress:    progress,
+			transaction: transaction,
+		}
+		if len(renderSpec.Generators) > 0 {
+			run.invokedAs = fmt.Sprintf("generator #%d (%s)", n+1, invocation.GeneratorName)
+		}
+
+		generator, includes, err := i.prepareInvocation(ctx, run, &invocation)
+		if err != nil {
+			if run.invokedAs != "" {
+				err = fmt.Errorf("error preparing %s: %s", run.invokedAs, err)
+			}
+			return i.errorResponseToplevel(ctx, err)
+		}
+		if err := checkGeneratorVersion(run.genSpec, &invocation); err != nil {
+			if run.invokedAs != "" {
+				err = fmt.Errorf("%s: %s", run.invokedAs, err)
+			}
+			if request.FailOnIncompatibleVersion {
+				return i.errorResponseToplevel(ctx, err)
+			}
+			warnings = append(warnings, err.Error())
+		}
+		requested = append(requested, generator)
+		generators = append(generators, generator)
+		generators = append(generators, includes...)
+	}
+
+	templatesTotal := 0
+	for _, generator := range generators {
+		templatesTotal += len(generator.run.genSpec.Templates)
+	}
+	progress.started(ctx, templatesTotal)
+
+	hookResults := []api.HookResult{}
+	executeHooks := request.ExecuteHooks && !request.DryRun
+	if executeHooks {
+		for _, generator := range requested {
+			preRenderResults, success := i.runHooks(ctx, generator.run, "pre render", generator.run.genSpec.PreRenderHooks, generator.parameters)
+			hookResults = append(hookResults, preRenderResults...)
+			if !success {
+				response := i.errorResponseToplevel(ctx, errors.New("a pre render hook failed, nothing was rendered, see hook results"))
+				response.HookResults = hookResults
+				response.Warnings = warnings
+				return response
+			}
+		}
 	}
 
-	renderedFiles, allSuccessful := i.renderAllTemplates(ctx, genSpec, parameters, sourceDir, targetDir)
+	renderedFiles, allSuccessful := i.renderAllTemplates(ctx, generators)
+	var response *api.Response
 	if allSuccessful {
-		return i.successResponse(ctx, renderedFiles)
+		response = i.successResponse(ctx, renderedFiles)
 	} else {
-		return i.errorResponseRender(ctx, renderedFiles)
+		response = i.errorResponseRender(ctx, renderedFiles)
 	}
+	if !allSuccessful && ctx.Err() != nil {
+		response.Errors = append(response.Errors, fmt.Errorf("rendering was cancelled: %w", ctx.Err()))
+	}
+	if transaction != nil {
+		if !allSuccessful {
+			response.Errors = append(response.Errors, errors.New("rendering is transactional, so no files were written"))
+		} else if err := transaction.Commit(ctx); err != nil {
+			allSuccessful = false
+			response.Success = false
+			response.Errors = append(response.Errors, fmt.Errorf("failed to write rendered files, no files were changed: %s", err))
+			progress.failed(ctx, response.Errors[len(response.Errors)-1])
+		}
+	}
+
+	if executeHooks && allSuccessful {
+		for _, generator := range requested {
+			postRenderResults, success := i.runHooks(ctx, generator.run, "post render", generator.run.genSpec.PostRenderHooks, generator.parameters)
+			hookResults = append(hookResults, postRenderResults...)
+			if !success {
+				response.Success = false
+				response.Errors = append(response.Errors, errors.New("a post render hook failed, see hook results"))
+				break
+			}
+		}
+	}
+	if len(hookResults) > 0 {
+		response.HookResults = hookResults
+	}
+	if len(warnings) > 0 {
+		response.Warnings = warnings
+	}
+	return response
 }
 
-// helper functions
+// renderSpecInvocations returns the generators to render according to the render spec, in order.
+func renderSpecInvocations(renderSpec *api.RenderSpec) ([]api.GeneratorInvocation, error) {
+	if len(renderSpec.Generators) == 0 {
+		return []api.GeneratorInvocation{{
+			GeneratorName:    renderSpec.GeneratorName,
+			GeneratorVersion: renderSpec.GeneratorVersion,
+			Parameters:       renderSpec.Parameters,
+		}}, nil
+	}
+	if renderSpec.GeneratorName != "" || renderSpec.GeneratorVersion != "" || len(renderSpec.Parameters) > 0 {
+		return nil, errors.New("render spec must either specify generator and parameters or a list of generators, not both")
+	}
+	return renderSpec.Generators, nil
+}
+
+// obtainGeneratorSpec reads the spec of a generator named in a render spec or request.
+//
+// If the request has a catalog, the name is looked up in the catalog. Otherwise, the generator is read from sourceDir.
+func (i *GeneratorImpl) obtainGeneratorSpec(ctx context.Context, request *api.Request, sourceDir *generatordir.GeneratorDirectory, generatorName string) (*api.GeneratorSpec, error) {
+	sourceDir, generatorName, err := i.locateGenerator(ctx, request, sourceDir, generatorName)
+	if err != nil {
+		return &api.GeneratorSpec{}, err
+	}
+	return sourceDir.ObtainGeneratorSpec(ctx, generatorName)
+}
+
+// locateGenerator returns the generator directory and the plain name of a generator named in a render spec or request.
+func (i *GeneratorImpl) locateGenerator(ctx context.Context, request *api.Request, sourceDir *generatordir.GeneratorDirectory, generatorName string) (*generatordir.GeneratorDirectory, string, error) {
+	if request.Catalog == nil {
+		return sourceDir, generatorName, nil
+	}
+	entry, err := request.Catalog.Find(generatorName)
+	if err != nil {
+		return sourceDir, generatorName, err
+	}
+	return generatordir.Instance(ctx, entry.SourceBaseDir), entry.Info.Name, nil
+}
+
+// checkGeneratorVersion returns an error if the render spec was written for a different major version of the generator.
+//
+// There is nothing to check unless both the generator spec and the render spec specify a version.
+func checkGeneratorVersion(genSpec *api.GeneratorSpec, invocation *api.GeneratorInvocation) error {
+	if genSpec.Version == "" || invocation.GeneratorVersion == "" {
+		return nil
+	}
+	current, err := semver.NewVersion(genSpec.Version)
+	if err != nil {
+		// unreachable, the generator spec has already been validated
+		return err
+	}
+	recorded, err := semver.NewVersion(invocation.GeneratorVersion)
+	if err != nil {
+		return fmt.Errorf("render spec was written for version '%s' of generator %s, which is not a valid semantic version", invocation.GeneratorVersion, invocation.GeneratorName)
+	}
+	if recorded.Major() != current.Major() {
+		return fmt.Errorf("render spec was written for version %s of generator %s, which is incompatible with its current version %s", invocation.GeneratorVersion, invocation.GeneratorName, genSpec.Version)
+	}
+	return nil
+}
+
+// prepareInvocation obtains the generator spec and parameters for a generator named in the render spec, and
+// resolves the generators it includes.
+func (i *GeneratorImpl) prepareInvocation(ctx context.Context, run *renderRun, invocation *api.GeneratorInvocation) (*activeGenerator, []*activeGenerator, error) {
+	sourceDir, generatorName, err := i.locateGenerator(ctx, run.request, run.sourceDir, invocation.GeneratorName)
+	if err != nil {
+		return nil, nil, err
+	}
+	genSpec, err := sourceDir.ObtainGeneratorSpec(ctx, generatorName)
+	if err != nil {
+		return nil, nil, err
+	}
+	run.sourceDir = sourceDir
+	run.genSpec = genSpec
+
+	parameters, err := i.constructAndValidateParameterMap(ctx, genSpec, &api.RenderSpec{
+		GeneratorName: invocation.GeneratorName,
+		Parameters:    invocation.Parameters,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if invocation.TargetDir != "" {
+		run.targetPrefix = path.Clean(invocation.TargetDir)
+		if run.targetPrefix == ".." || strings.HasPrefix(run.targetPrefix, "../") || path.IsAbs(run.targetPrefix) {
+			return nil, nil, fmt.Errorf("target %s of generator %s is not inside the target directory - this is forbidden", invocation.TargetDir, invocation.GeneratorName)
+		}
+	}
+
+	includes, err := i.resolveIncludes(ctx, run, parameters, []string{path.Join(path.Clean(run.sourceDir.BaseDir()), generatorName)})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &activeGenerator{run: run, parameters: parameters}, includes, nil
+}
 
 func (i *GeneratorImpl) constructRenderSpecWithValuesOrDefaults(_ context.Context, generatorName string, genSpec *api.GeneratorSpec, parameters map[string]interface{}, nilDefault interface{}) (*api.RenderSpec, error) {
 	renderSpec := &api.RenderSpec{
-		GeneratorName: generatorName,
-		Parameters:    map[string]interface{}{},
+		GeneratorName:    generatorName,
+		GeneratorVersion: genSpec.Version,
+		Parameters:       map[string]interface{}{},
+		ParameterOrder:   genSpec.VariableOrder,
 	}
 	for k, v := range genSpec.Variables {
 		// a fetch on a map missing key will produce the empty value for that type, i.e. nil here
@@ -144,9 +377,36 @@ func (i *GeneratorImpl) constructRenderSpecWithValuesOrDefaults(_ context.Contex
 			}
 		}
 	}
+	renderSpec.ParameterComments, renderSpec.ParameterWarnings = parameterAnnotations(genSpec, renderSpec.Parameters)
 	return renderSpec, nil
 }
 
+// parameterAnnotations describes the parameters of a generator for the comments in a render spec file, and warns
+// about required parameters that have no value yet.
+func parameterAnnotations(genSpec *api.GeneratorSpec, parameters map[string]interface{}) (map[string]string, map[string]string) {
+	comments := make(map[string]string)
+	warnings := make(map[string]string)
+	for name, variable := range genSpec.Variables {
+		lines := []string{}
+		if variable.Description != "" {
+			lines = append(lines, variable.Description)
+		}
+		requirement := "optional"
+		if variable.DefaultValue == nil {
+			requirement = "required"
+		}
+		if variable.ValidationPattern != "" {
+			requirement += ", must match " + variable.ValidationPattern
+		}
+		comments[name] = strings.Join(append(lines, requirement), "\n")
+
+		if value := parameters[name]; variable.DefaultValue == nil && (value == nil || value == "") {
+			warnings[name] = "required, please provide a value"
+		}
+	}
+	return comments, warnings
+}
+
 func (i *GeneratorImpl) renderStringDefaultFromTemplate(variableName string, defaultStr string) (interface{}, error) {
 	templateName := "__defaultvalue_" + variableName
 	tmpl, err := template.New(templateName).Funcs(sprig.TxtFuncMap()).Parse(defaultStr)
@@ -198,116 +458,302 @@ func (i *GeneratorImpl) constructAndValidateParameterMap(_ context.Context, genS
 	return parameters, nil
 }
 
-func (i *GeneratorImpl) renderAllTemplates(ctx context.Context, genSpec *api.GeneratorSpec, parameters map[string]interface{}, sourceDir *generatordir.GeneratorDirectory, targetDir *targetdir.TargetDirectory) ([]api.FileResult, bool) {
-	var renderedFiles []api.FileResult
-	allSuccessful := true
-	for _, tplSpec := range genSpec.Templates {
-		rendered, success := i.renderSingleTemplateWithFiles(ctx, &tplSpec, parameters, sourceDir, targetDir)
-		renderedFiles = append(renderedFiles, rendered...)
-		allSuccessful = allSuccessful && success
+// checkExtraneousParameters makes sure there are no parameters for variables the generator spec does not declare.
+func checkExtraneousParameters(genSpec *api.GeneratorSpec, parameters map[string]interface{}) error {
+	names := make([]string, 0, len(parameters))
+	for k := range parameters {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if _, ok := genSpec.Variables[k]; !ok {
+			return fmt.Errorf("parameter '%s' is not allowed according to generator spec", k)
+		}
+	}
+	return nil
+}
+
+// renderAllTemplates renders the templates of all generators in order. They share the request of the first one.
+func (i *GeneratorImpl) renderAllTemplates(ctx context.Context, generators []*activeGenerator) ([]api.FileResult, bool) {
+	jobs := []*renderJob{}
+	pending := []*api.TemplateSpec{}
+	for _, generator := range generators {
+		var generatorJobs []*renderJob
+		generatorJobs, pending = i.planAllTemplates(ctx, generator.run, generator.parameters, pending)
+		jobs = append(jobs, generatorJobs...)
+	}
+	renderedFiles, allSuccessful := i.executeJobs(ctx, generators[0].run, jobs)
+	for _, tplSpec := range pending {
+		generators[0].run.progress.templateStarted(ctx, tplSpec)
 	}
 	return renderedFiles, allSuccessful
 }
 
-func (i *GeneratorImpl) renderSingleTemplateWithFiles(ctx context.Context, tplSpec *api.TemplateSpec, parameters map[string]interface{}, sourceDir *generatordir.GeneratorDirectory, targetDir *targetdir.TargetDirectory) ([]api.FileResult, bool) {
-	if len(tplSpec.WithFiles) > 0 {
-		fileList := make([]string, 0)
-		for _, relativeGlobExpression := range tplSpec.WithFiles {
-			matches, err := sourceDir.Glob(ctx, relativeGlobExpression)
-			if err != nil {
-				return []api.FileResult{i.errorFileResult(ctx, tplSpec.RelativeTargetPath, fmt.Errorf("failed to resolve template glob %s: %s", relativeGlobExpression, err))}, false
-			}
-			fileList = append(fileList, matches...)
+// planAllTemplates evaluates target paths and conditions for all templates, in order, without writing anything.
+//
+// Each template is reported as started when its first job is executed. pending lists the templates that have
+// not produced any jobs yet, they are reported together with the next job. Returns the templates still pending.
+func (i *GeneratorImpl) planAllTemplates(ctx context.Context, run *renderRun, parameters map[string]interface{}, pending []*api.TemplateSpec) ([]*renderJob, []*api.TemplateSpec) {
+	jobs := []*renderJob{}
+	for n, tplSpec := range run.genSpec.Templates {
+		if ctx.Err() != nil {
+			jobs = append(jobs, errorJob(tplSpec.RelativeTargetPath, fmt.Errorf("template #%d (%s) was not rendered: %w", n+1, tplSpec.RelativeSourcePath, ctx.Err())))
+			continue
+		}
+		pending = append(pending, &run.genSpec.Templates[n])
+		if tplSpec.LeftDelimiter == "" && tplSpec.RightDelimiter == "" {
+			tplSpec.LeftDelimiter = run.genSpec.LeftDelimiter
+			tplSpec.RightDelimiter = run.genSpec.RightDelimiter
+		}
+		if tplSpec.Format == "" {
+			tplSpec.Format = run.genSpec.Format
 		}
+		tplSpec := tplSpec
+		for _, job := range i.planSingleTemplateWithFiles(ctx, run, &tplSpec, parameters) {
+			job.templateNumber = n + 1
+			job.run = run
+			job.startsTemplates, pending = pending, nil
+			jobs = append(jobs, job)
+		}
+	}
+	return withTargetPrefix(jobs, run.targetPrefix), pending
+}
 
-		sort.Strings(fileList)
+// templateSourceDir returns the directory the source paths of a template are relative to, which differs from the
+// directory of the generator for templates inherited from a base generator in another directory.
+func templateSourceDir(ctx context.Context, run *renderRun, tplSpec *api.TemplateSpec) *generatordir.GeneratorDirectory {
+	return run.sourceDir.Relative(ctx, tplSpec.SourceDir)
+}
+
+func (i *GeneratorImpl) planSingleTemplateWithFiles(ctx context.Context, run *renderRun, tplSpec *api.TemplateSpec, parameters map[string]interface{}) []*renderJob {
+	if tplSpec.Directory != "" {
+		return i.planDirectoryTemplate(ctx, run, tplSpec, parameters)
+	} else if len(tplSpec.WithFiles) > 0 {
+		fileList, err := i.resolveFileGlobs(ctx, run, tplSpec)
+		if err != nil {
+			return []*renderJob{errorJob(tplSpec.RelativeTargetPath, err)}
+		}
 
-		renderedFiles := []api.FileResult{}
-		allSuccessful := true
+		jobs := []*renderJob{}
 		for counter, item := range fileList {
-			parameters["file"] = item
+			fileParameters := copyParameters(parameters)
+			fileParameters["file"] = item
 
 			tmpTplName := fmt.Sprintf("%s_path_source", strings.ReplaceAll(item, "/", "_"))
 
 			subTplSpec := *tplSpec
-			renderedSourcePath, err := i.renderString(ctx, parameters, tmpTplName, tplSpec.RelativeSourcePath)
+			renderedSourcePath, err := i.renderString(ctx, fileParameters, tmpTplName, tplSpec.RelativeSourcePath)
 			if err != nil {
-				renderedFiles = append(renderedFiles, i.errorFileResult(ctx, tplSpec.RelativeTargetPath, fmt.Errorf("failed to render source path from glob %s for file value %s -- skipping entry: %s", tplSpec.RelativeSourcePath, item, err)))
-				allSuccessful = false
+				jobs = append(jobs, errorJob(tplSpec.RelativeTargetPath, fmt.Errorf("failed to render source path from glob %s for file value %s -- skipping entry: %s", tplSpec.RelativeSourcePath, item, err)))
 			} else {
 				subTplSpec.RelativeSourcePath = renderedSourcePath
 
-				rendered, success := i.renderSingleTemplate(ctx, &subTplSpec, parameters, sourceDir, targetDir, fmt.Sprintf("_%d", counter+1), fmt.Sprintf(" for file #%d (%s)", counter+1, item))
-				if success {
-					renderedFiles = append(renderedFiles, rendered...)
-				} else {
-					allSuccessful = false
-				}
+				jobs = append(jobs, withFile(i.planSingleTemplate(ctx, run, &subTplSpec, fileParameters, fmt.Sprintf("_%d", counter+1), fmt.Sprintf(" for file #%d (%s)", counter+1, item), ""), item)...)
 			}
 		}
-		return renderedFiles, allSuccessful
+		return jobs
 	} else {
-		return i.renderSingleTemplate(ctx, tplSpec, parameters, sourceDir, targetDir, "", "")
+		return i.planSingleTemplate(ctx, run, tplSpec, parameters, "", "", "")
+	}
+}
+
+// resolveFileGlobs returns the sorted list of distinct files matched by the with_files patterns of a template.
+//
+// Patterns starting with '!' exclude any matching files, regardless of the order in which they are given.
+func (i *GeneratorImpl) resolveFileGlobs(ctx context.Context, run *renderRun, tplSpec *api.TemplateSpec) ([]string, error) {
+	matched := make(map[string]bool)
+	exclusions := make([]string, 0)
+	for _, relativeGlobExpression := range tplSpec.WithFiles {
+		if strings.HasPrefix(relativeGlobExpression, "!") {
+			exclusions = append(exclusions, strings.TrimPrefix(relativeGlobExpression, "!"))
+			continue
+		}
+
+		matches, err := templateSourceDir(ctx, run, tplSpec).Glob(ctx, relativeGlobExpression, tplSpec.IncludeHidden)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve template glob %s: %s", relativeGlobExpression, err)
+		}
+		for _, m := range matches {
+			matched[m] = true
+		}
+	}
+
+	fileList := make([]string, 0)
+	for candidate := range matched {
+		excluded := false
+		for _, exclusion := range exclusions {
+			// exclusions always apply to hidden files, too
+			matches, err := generatordir.MatchGlob(exclusion, candidate, true)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve template glob !%s: %s", exclusion, err)
+			}
+			excluded = excluded || matches
+		}
+		if !excluded {
+			fileList = append(fileList, candidate)
+		}
+	}
+
+	sort.Strings(fileList)
+	return fileList, nil
+}
+
+func (i *GeneratorImpl) planDirectoryTemplate(ctx context.Context, run *renderRun, tplSpec *api.TemplateSpec, parameters map[string]interface{}) []*renderJob {
+	if len(tplSpec.WithFiles) > 0 || len(tplSpec.WithItems) > 0 {
+		return []*renderJob{errorJob(tplSpec.RelativeTargetPath, fmt.Errorf("directory template %s cannot be combined with with_items or with_files", tplSpec.Directory))}
+	}
+
+	directory, err := i.renderString(ctx, parameters, "__directory", tplSpec.Directory)
+	if err != nil {
+		return []*renderJob{errorJob(tplSpec.RelativeTargetPath, fmt.Errorf("error evaluating directory from '%s': %s", tplSpec.Directory, err))}
+	}
+
+	fileList, err := templateSourceDir(ctx, run, tplSpec).ListFiles(ctx, directory)
+	if err != nil {
+		return []*renderJob{errorJob(tplSpec.RelativeTargetPath, fmt.Errorf("failed to list template directory %s: %s", directory, err))}
+	}
+
+	jobs := []*renderJob{}
+	for counter, item := range fileList {
+		fileParameters := copyParameters(parameters)
+		fileParameters["file"] = item
+
+		targetPath, err := i.renderDirectoryTargetPath(ctx, tplSpec, fileParameters, directory, item, counter+1)
+		if err != nil {
+			jobs = append(jobs, errorJob(tplSpec.RelativeTargetPath, fmt.Errorf("failed to render target path for file #%d (%s) in directory %s -- skipping entry: %s", counter+1, item, directory, err)))
+			continue
+		}
+
+		subTplSpec := *tplSpec
+		subTplSpec.RelativeSourcePath = item
+		subTplSpec.RelativeTargetPath = targetPath
+		subTplSpec.JustCopy = tplSpec.JustCopy || !strings.HasSuffix(item, ".tmpl")
+
+		// the target path is already evaluated, evaluating it again would treat parameter values as templates
+		jobs = append(jobs, withFile(i.planSingleTemplate(ctx, run, &subTplSpec, fileParameters, fmt.Sprintf("_%d", counter+1), fmt.Sprintf(" for file #%d (%s)", counter+1, item), targetPath), item)...)
 	}
+	return jobs
 }
 
-func (i *GeneratorImpl) renderSingleTemplate(
+// renderDirectoryTargetPath evaluates every path segment of a file below a directory template as a template,
+// strips a .tmpl suffix, and places the result below the (also evaluated) target of the template spec.
+func (i *GeneratorImpl) renderDirectoryTargetPath(ctx context.Context, tplSpec *api.TemplateSpec, parameters map[string]interface{}, directory string, file string, counter int) (string, error) {
+	targetBase, err := i.renderString(ctx, parameters, fmt.Sprintf("__directory_target_%d", counter), tplSpec.RelativeTargetPath)
+	if err != nil {
+		return "", err
+	}
+
+	relativePath, err := filepath.Rel(directory, file)
+	if err != nil {
+		// unreachable, ListFiles only returns files below the directory
+		return "", err
+	}
+	relativePath = filepath.ToSlash(relativePath)
+	segments := strings.Split(strings.TrimSuffix(relativePath, ".tmpl"), "/")
+	for n, segment := range segments {
+		segments[n], err = i.renderString(ctx, parameters, fmt.Sprintf("__directory_target_%d_%d", counter, n+1), segment)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	targetPath := path.Join(targetBase, path.Join(segments...))
+	if targetPath == ".." || strings.HasPrefix(targetPath, "../") || path.IsAbs(targetPath) {
+		return "", fmt.Errorf("target path %s is not inside the target directory - this is forbidden", targetPath)
+	}
+	return targetPath, nil
+}
+
+func (i *GeneratorImpl) planSingleTemplate(
 	ctx context.Context,
+	run *renderRun,
 	tplSpec *api.TemplateSpec,
 	parameters map[string]interface{},
-	sourceDir *generatordir.GeneratorDirectory,
-	targetDir *targetdir.TargetDirectory,
 	templateNameExtension string,
 	errorMessageItemExtension string,
-) ([]api.FileResult, bool) {
+	evaluatedTargetPath string,
+) []*renderJob {
 	templateName := strings.ReplaceAll(tplSpec.RelativeSourcePath, "/", "_")
-	templateContents, err := sourceDir.ReadFile(ctx, tplSpec.RelativeSourcePath)
+	templateContents, err := templateSourceDir(ctx, run, tplSpec).ReadFile(ctx, tplSpec.RelativeSourcePath)
 	if err != nil {
-		return []api.FileResult{i.errorFileResult(ctx, tplSpec.RelativeTargetPath, fmt.Errorf("failed to load template %s: %s", tplSpec.RelativeSourcePath, err))}, false
+		return []*renderJob{errorJob(tplSpec.RelativeTargetPath, fmt.Errorf("failed to load template %s: %s", tplSpec.RelativeSourcePath, err))}
 	}
 
-	tmplw, err := templatewrapper.New(tplSpec.JustCopy, templateContents, templateName, tplSpec.RelativeSourcePath).Parse()
+	justCopy := tplSpec.JustCopy || i.isBinaryFile(ctx, run, tplSpec.RelativeSourcePath, templateContents)
+	tmplw, err := templatewrapper.New(justCopy, templateContents, templateName, tplSpec.RelativeSourcePath).
+		WithDelimiters(tplSpec.LeftDelimiter, tplSpec.RightDelimiter).
+		Parse()
 	if err != nil {
-		return []api.FileResult{i.errorFileResult(ctx, tplSpec.RelativeTargetPath, fmt.Errorf("failed to parse template %s: %s", tplSpec.RelativeSourcePath, err))}, false
+		return []*renderJob{errorJob(tplSpec.RelativeTargetPath, fmt.Errorf("failed to parse template %s: %s", tplSpec.RelativeSourcePath, err))}
 	}
 
-	renderedFiles := []api.FileResult{}
-	allSuccessful := true
 	if len(tplSpec.WithItems) > 0 {
+		jobs := []*renderJob{}
 		for counter, item := range tplSpec.WithItems {
-			parameters["item"] = item
-			renderedFiles, allSuccessful = i.renderSingleTemplateIteration(ctx, tplSpec, parameters, templateName, fmt.Sprintf("_%d", counter+1),
-				fmt.Sprintf(" for item #%d", counter+1), renderedFiles, allSuccessful, tmplw, targetDir)
+			itemParameters := copyParameters(parameters)
+			itemParameters["item"] = item
+			job := i.planSingleTemplateIteration(ctx, tplSpec, itemParameters, templateName, fmt.Sprintf("_%d", counter+1),
+				fmt.Sprintf(" for item #%d", counter+1), tmplw, evaluatedTargetPath)
+			job.item = item
+			jobs = append(jobs, job)
 		}
+		return jobs
 	} else {
-		renderedFiles, allSuccessful = i.renderSingleTemplateIteration(ctx, tplSpec, parameters, templateName, "",
-			"", renderedFiles, allSuccessful, tmplw, targetDir)
+		return []*renderJob{i.planSingleTemplateIteration(ctx, tplSpec, parameters, templateName, "", "", tmplw, evaluatedTargetPath)}
 	}
-	return renderedFiles, allSuccessful
 }
 
-func (i *GeneratorImpl) renderSingleTemplateIteration(ctx context.Context, tplSpec *api.TemplateSpec, parameters map[string]interface{}, templateName string, templateNameExtension string,
-	errorMessageItemExtension string, renderedFiles []api.FileResult, allSuccessful bool, tmpl *templatewrapper.TemplateWrapper, targetDir *targetdir.TargetDirectory) ([]api.FileResult, bool) {
-	targetPath, err := i.renderString(ctx, parameters, fmt.Sprintf("%s_path%s", templateName, templateNameExtension), tplSpec.RelativeTargetPath)
-	if err != nil {
-		renderedFiles = append(renderedFiles, i.errorFileResult(ctx, targetPath, fmt.Errorf("error evaluating target path from '%s'%s: %s", tplSpec.RelativeTargetPath, errorMessageItemExtension, err)))
-		allSuccessful = false
-	} else {
-		condition, err := i.evaluateCondition(ctx, tplSpec.Condition, parameters, fmt.Sprintf("%s_condition%s", templateName, templateNameExtension))
+func (i *GeneratorImpl) planSingleTemplateIteration(ctx context.Context, tplSpec *api.TemplateSpec, parameters map[string]interface{}, templateName string, templateNameExtension string,
+	errorMessageItemExtension string, tmpl *templatewrapper.TemplateWrapper, evaluatedTargetPath string) *renderJob {
+	// evaluatedTargetPath is set if the caller has already evaluated the target path
+	targetPath := evaluatedTargetPath
+	if targetPath == "" {
+		var err error
+		targetPath, err = i.renderString(ctx, parameters, fmt.Sprintf("%s_path%s", templateName, templateNameExtension), tplSpec.RelativeTargetPath)
 		if err != nil {
-			renderedFiles = append(renderedFiles, i.errorFileResult(ctx, targetPath, fmt.Errorf("error evaluating condition from '%s'%s: %s", tplSpec.Condition, errorMessageItemExtension, err)))
-			allSuccessful = false
-		} else if condition {
-			err := i.renderAndWriteFile(ctx, parameters, tmpl, templateName, targetDir, targetPath)
-			if err != nil {
-				renderedFiles = append(renderedFiles, i.errorFileResult(ctx, targetPath, fmt.Errorf("error evaluating template for target '%s'%s: %s", targetPath, errorMessageItemExtension, err)))
-				allSuccessful = false
-			} else {
-				renderedFiles = append(renderedFiles, i.successFileResult(ctx, targetPath))
+			return errorJob(targetPath, fmt.Errorf("error evaluating target path from '%s'%s: %s", tplSpec.RelativeTargetPath, errorMessageItemExtension, err))
+		}
+	}
+
+	condition, err := i.evaluateCondition(ctx, tplSpec.Condition, parameters, fmt.Sprintf("%s_condition%s", templateName, templateNameExtension))
+	if err != nil {
+		return errorJob(targetPath, fmt.Errorf("error evaluating condition from '%s'%s: %s", tplSpec.Condition, errorMessageItemExtension, err))
+	}
+
+	return &renderJob{
+		tplSpec:                   tplSpec,
+		parameters:                parameters,
+		tmpl:                      tmpl,
+		templateName:              templateName,
+		templateNameExtension:     templateNameExtension,
+		errorMessageItemExtension: errorMessageItemExtension,
+		targetPath:                targetPath,
+		skipped:                   !condition,
+	}
+}
+
+// isBinaryFile decides whether a template file must be copied verbatim instead of being parsed as a template.
+//
+// The extension lists in the generator spec take precedence, otherwise the file contents are inspected.
+func (i *GeneratorImpl) isBinaryFile(_ context.Context, run *renderRun, relativeSourcePath string, contents []byte) bool {
+	extension := strings.ToLower(path.Ext(strings.TrimSuffix(relativeSourcePath, ".tmpl")))
+	if extension != "" {
+		for _, textExtension := range run.genSpec.TextExtensions {
+			if extension == normalizeExtension(textExtension) {
+				return false
+			}
+		}
+		for _, binaryExtension := range run.genSpec.BinaryExtensions {
+			if extension == normalizeExtension(binaryExtension) {
+				return true
 			}
 		}
 	}
-	return renderedFiles, allSuccessful
+	return templatewrapper.IsBinary(contents)
+}
+
+func normalizeExtension(extension string) string {
+	return "." + strings.TrimPrefix(strings.ToLower(extension), ".")
 }
 
 func (i *GeneratorImpl) evaluateCondition(ctx context.Context, condition string, parameters map[string]interface{}, templateName string) (bool, error) {
@@ -321,16 +767,92 @@ func (i *GeneratorImpl) evaluateCondition(ctx context.Context, condition string,
 	return rendered != "false" && rendered != "0" && rendered != "no" && rendered != "skip", nil
 }
 
-func (i *GeneratorImpl) renderAndWriteFile(ctx context.Context, parameters map[string]interface{}, tmplw *templatewrapper.TemplateWrapper, templateName string, targetDir *targetdir.TargetDirectory, targetPath string) error {
+// evaluateFileMode returns the file mode to set on the target file, or 0 if the mode should not be changed.
+func (i *GeneratorImpl) evaluateFileMode(ctx context.Context, run *renderRun, tplSpec *api.TemplateSpec, parameters map[string]interface{}, templateName string) (os.FileMode, error) {
+	if tplSpec.Mode != "" {
+		rendered, err := i.renderString(ctx, parameters, templateName, tplSpec.Mode)
+		if err != nil {
+			return 0, err
+		}
+		mode, err := strconv.ParseUint(rendered, 8, 32)
+		if err != nil || mode == 0 || mode > 0777 {
+			return 0, fmt.Errorf("mode '%s' is not a valid octal file permission like '0755'", rendered)
+		}
+		return os.FileMode(mode), nil
+	}
+	if tplSpec.InheritMode {
+		return templateSourceDir(ctx, run, tplSpec).FileMode(ctx, tplSpec.RelativeSourcePath)
+	}
+	return 0, nil
+}
+
+func (i *GeneratorImpl) renderFormatAndWriteFile(ctx context.Context, run *renderRun, job *renderJob) api.FileResult {
+	tplSpec, parameters, templateName, tmpl, targetPath := job.tplSpec, job.parameters, job.templateName, job.tmpl, job.targetPath
+	templateNameExtension, errorMessageItemExtension := job.templateNameExtension, job.errorMessageItemExtension
+
+	mode, err := i.evaluateFileMode(ctx, run, tplSpec, parameters, fmt.Sprintf("%s_mode%s", templateName, templateNameExtension))
+	if err != nil {
+		return i.errorFileResult(ctx, targetPath, fmt.Errorf("error evaluating file mode for target '%s'%s: %s", targetPath, errorMessageItemExtension, err))
+	}
+
+	contents, err := i.renderFile(ctx, parameters, tmpl, templateName)
+	if err != nil {
+		return i.errorFileResult(ctx, targetPath, fmt.Errorf("error evaluating template for target '%s'%s: %s", targetPath, errorMessageItemExtension, err))
+	}
+
+	if !tmpl.IsRawFile() {
+		formatted, err := formatter.Format(tplSpec.Format, targetPath, contents)
+		if err != nil {
+			return i.errorFileResult(ctx, targetPath, fmt.Errorf("error formatting target '%s'%s: %s\n--- unformatted output ---\n%s", targetPath, errorMessageItemExtension, err, string(contents)))
+		}
+		contents = formatted
+
+		for n, postProcessor := range run.request.PostProcessors {
+			processed, err := postProcessor.Process(ctx, targetPath, contents)
+			if err != nil {
+				return i.errorFileResult(ctx, targetPath, fmt.Errorf("error in post processor #%d for target '%s'%s: %s", n+1, targetPath, errorMessageItemExtension, err))
+			}
+			contents = processed
+		}
+	}
+
+	action, err := i.writeFile(ctx, run, targetPath, contents, mode)
+	if err != nil {
+		return i.errorFileResult(ctx, targetPath, fmt.Errorf("error evaluating template for target '%s'%s: %s", targetPath, errorMessageItemExtension, err))
+	}
+
+	result := i.successFileResult(ctx, targetPath)
+	result.Copied = tmpl.IsRawFile()
+	result.Action = action
+	if result.Copied && action != api.FileUnchanged {
+		result.Action = api.FileCopied
+	}
+	result.Size = len(contents)
+	result.Hash = contentHash(contents)
+	return result
+}
+
+func (i *GeneratorImpl) renderFile(_ context.Context, parameters map[string]interface{}, tmplw *templatewrapper.TemplateWrapper, templateName string) ([]byte, error) {
 	var buf bytes.Buffer
 	err := tmplw.Write(&buf, templateName, parameters)
 	if err != nil {
 		// unsure if this is reachable. All errors I've been able to produce are found during template parse
-		return err
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	err = targetDir.WriteFile(ctx, targetPath, buf.Bytes())
-	return err
+// writeFile writes the file unless its contents are unchanged, and reports what it did.
+//
+// In a dry run, it only reports what it would do. In transactional mode, the file is only written on commit.
+func (i *GeneratorImpl) writeFile(ctx context.Context, run *renderRun, targetPath string, contents []byte, mode os.FileMode) (api.FileAction, error) {
+	if run.request.DryRun {
+		return run.targetDir.CompareFile(ctx, targetPath, contents, mode)
+	}
+	if run.transaction != nil {
+		return run.transaction.WriteFile(ctx, targetPath, contents, mode)
+	}
+	return run.targetDir.WriteFileIfChanged(ctx, targetPath, contents, mode)
 }
 
 func (i *GeneratorImpl) renderString(_ context.Context, parameters map[string]interface{}, templateName string, templateContents string) (string, error) {
@@ -349,6 +871,19 @@ func (i *GeneratorImpl) renderString(_ context.Context, parameters map[string]in
 	return buf.String(), nil
 }
 
+func contentHash(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+func copyParameters(parameters map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(parameters))
+	for k, v := range parameters {
+		result[k] = v
+	}
+	return result
+}
+
 // --- response helpers
 
 func (i *GeneratorImpl) errorResponseToplevel(_ context.Context, err error) *api.Response {
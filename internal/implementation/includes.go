@@ -0,0 +1,113 @@
+package implementation
+
+import (
+	"context"
+	"fmt"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"path"
+	"strings"
+)
+
+// activeGenerator is a generator that takes part in a render run, with its own parameters.
+type activeGenerator struct {
+	run        *renderRun
+	parameters map[string]interface{}
+}
+
+// resolveIncludes returns all generators included by the generator of the run, depth first, in order.
+//
+// chain lists the generators that are currently being resolved, to detect include cycles.
+func (i *GeneratorImpl) resolveIncludes(ctx context.Context, run *renderRun, parameters map[string]interface{}, chain []string) ([]*activeGenerator, error) {
+	result := []*activeGenerator{}
+	for n, include := range run.genSpec.Includes {
+		templateName := fmt.Sprintf("__include_%d", n+1)
+		condition, err := i.evaluateCondition(ctx, include.Condition, parameters, templateName+"_condition")
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating condition from '%s' for include #%d (%s): %s", include.Condition, n+1, include.Generator, err)
+		}
+		if !condition {
+			continue
+		}
+
+		sourceDir := run.sourceDir.Relative(ctx, include.SourceDir)
+		key := path.Join(path.Clean(sourceDir.BaseDir()), include.Generator)
+		for _, active := range chain {
+			if active == key {
+				return nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(chain, " -> "), key)
+			}
+		}
+
+		genSpec, err := sourceDir.ObtainGeneratorSpec(ctx, include.Generator)
+		if err != nil {
+			return nil, fmt.Errorf("error obtaining included generator %s: %s", include.Generator, err)
+		}
+
+		subParameters, err := i.includeParameters(ctx, &include, genSpec, parameters, templateName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters for included generator %s: %s", include.Generator, err)
+		}
+
+		targetDir, err := i.renderString(ctx, parameters, templateName+"_target", include.TargetDir)
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating target from '%s' for include #%d (%s): %s", include.TargetDir, n+1, include.Generator, err)
+		}
+		targetPrefix := path.Join(run.targetPrefix, targetDir)
+		if targetPrefix == ".." || strings.HasPrefix(targetPrefix, "../") || path.IsAbs(targetPrefix) {
+			return nil, fmt.Errorf("target %s of included generator %s is not inside the target directory - this is forbidden", targetPrefix, include.Generator)
+		}
+
+		subRun := *run
+		subRun.genSpec = genSpec
+		subRun.sourceDir = sourceDir
+		subRun.targetPrefix = targetPrefix
+		subRun.includedAs = include.Generator
+		result = append(result, &activeGenerator{run: &subRun, parameters: subParameters})
+
+		nested, err := i.resolveIncludes(ctx, &subRun, subParameters, append(chain, key))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, nested...)
+	}
+	return result, nil
+}
+
+// includeParameters evaluates the parameter mapping of an include and validates the result against the included generator.
+func (i *GeneratorImpl) includeParameters(ctx context.Context, include *api.IncludeSpec, genSpec *api.GeneratorSpec, parameters map[string]interface{}, templateName string) (map[string]interface{}, error) {
+	mapped := make(map[string]interface{})
+	for k, v := range include.Parameters {
+		if _, ok := genSpec.Variables[k]; !ok {
+			return nil, fmt.Errorf("parameter '%s' is not allowed according to generator spec", k)
+		}
+		if valueStr, ok := v.(string); ok {
+			rendered, err := i.renderString(ctx, parameters, fmt.Sprintf("%s_parameter_%s", templateName, k), valueStr)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating parameter '%s' from '%s': %s", k, valueStr, err)
+			}
+			mapped[k] = rendered
+		} else {
+			mapped[k] = v
+		}
+	}
+
+	return i.constructAndValidateParameterMap(ctx, genSpec, &api.RenderSpec{
+		GeneratorName: include.Generator,
+		Parameters:    mapped,
+	})
+}
+
+// withTargetPrefix places the target paths of all jobs below the target directory of an included generator.
+func withTargetPrefix(jobs []*renderJob, targetPrefix string) []*renderJob {
+	if targetPrefix == "" {
+		return jobs
+	}
+	for _, job := range jobs {
+		if job.targetPath != "" {
+			job.targetPath = path.Join(targetPrefix, job.targetPath)
+		}
+		if job.result != nil && job.result.RelativeFilePath != "" {
+			job.result.RelativeFilePath = path.Join(targetPrefix, job.result.RelativeFilePath)
+		}
+	}
+	return jobs
+}
@@ -0,0 +1,168 @@
+package implementation
+
+import (
+	"context"
+	"fmt"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/internal/implementation/templatewrapper"
+	"path"
+	"sync"
+)
+
+// renderJob is a single target file, planned before anything is written.
+//
+// Every job has its own copy of the parameters, so jobs can be executed concurrently.
+type renderJob struct {
+	tplSpec                   *api.TemplateSpec
+	parameters                map[string]interface{}
+	tmpl                      *templatewrapper.TemplateWrapper
+	templateName              string
+	templateNameExtension     string
+	errorMessageItemExtension string
+	targetPath                string
+
+	// the render run of the generator the template belongs to, which differs for included generators
+	run *renderRun
+
+	// the number of the template in the generator spec, counting from 1
+	templateNumber int
+
+	// the templates to report as started right before this job is executed: its own template if this is its
+	// first job, preceded by any earlier templates that did not produce any jobs
+	startsTemplates []*api.TemplateSpec
+
+	// the values of item and file for this iteration, if any
+	item interface{}
+	file string
+
+	// the condition of the template evaluated to false
+	skipped bool
+
+	// set if the job already failed during planning
+	result *api.FileResult
+}
+
+func errorJob(relativeFilePath string, err error) *renderJob {
+	return &renderJob{
+		targetPath: relativeFilePath,
+		result: &api.FileResult{
+			Success:          false,
+			RelativeFilePath: relativeFilePath,
+			Errors:           []error{err},
+		},
+	}
+}
+
+// withFile sets the file value on all the jobs.
+func withFile(jobs []*renderJob, file string) []*renderJob {
+	for _, job := range jobs {
+		job.file = file
+	}
+	return jobs
+}
+
+// source describes where the job came from, for error messages.
+func (j *renderJob) source() string {
+	if j.run != nil && j.run.includedAs != "" {
+		return fmt.Sprintf("template #%d (%s) of included generator %s%s", j.templateNumber, j.tplSpec.RelativeSourcePath, j.run.includedAs, j.errorMessageItemExtension)
+	}
+	if j.run != nil && j.run.invokedAs != "" {
+		return fmt.Sprintf("template #%d (%s) of %s%s", j.templateNumber, j.tplSpec.RelativeSourcePath, j.run.invokedAs, j.errorMessageItemExtension)
+	}
+	return fmt.Sprintf("template #%d (%s)%s", j.templateNumber, j.tplSpec.RelativeSourcePath, j.errorMessageItemExtension)
+}
+
+// executeJobs renders and writes the planned files, with up to request.Parallelism jobs running at the same time.
+//
+// The results are always in the order of the jobs, no matter in which order they complete.
+func (i *GeneratorImpl) executeJobs(ctx context.Context, run *renderRun, jobs []*renderJob) ([]api.FileResult, bool) {
+	i.detectConflictingTargets(jobs)
+	parallelism := run.request.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make([]*api.FileResult, len(jobs))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for n, job := range jobs {
+		for _, tplSpec := range job.startsTemplates {
+			run.progress.templateStarted(ctx, tplSpec)
+		}
+		if job.result != nil || job.skipped || parallelism == 1 {
+			// nothing to wait for, or nothing to gain, so keep the progress events in order
+			results[n] = i.executeJob(ctx, run, job)
+			continue
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(n int, job *renderJob) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			results[n] = i.executeJob(ctx, run, job)
+		}(n, job)
+	}
+	wg.Wait()
+
+	renderedFiles := []api.FileResult{}
+	allSuccessful := true
+	for _, result := range results {
+		renderedFiles = append(renderedFiles, *result)
+		allSuccessful = allSuccessful && result.Success
+	}
+	return renderedFiles, allSuccessful
+}
+
+func (i *GeneratorImpl) executeJob(ctx context.Context, run *renderRun, job *renderJob) *api.FileResult {
+	var result api.FileResult
+	if job.result != nil {
+		result = run.progress.fileDone(ctx, *job.result)
+	} else if job.skipped {
+		run.progress.fileSkipped(ctx, job.targetPath)
+		result = i.successFileResult(ctx, job.targetPath)
+		result.Action = api.FileSkipped
+	} else if ctx.Err() != nil {
+		result = run.progress.fileDone(ctx, i.errorFileResult(ctx, job.targetPath, fmt.Errorf("%s was not rendered: %w", job.source(), ctx.Err())))
+	} else {
+		result = run.progress.fileDone(ctx, i.renderFormatAndWriteFile(ctx, job.run, job))
+	}
+	if job.tplSpec != nil {
+		// templates inherited from a base generator in another directory are read relative to that directory
+		result.SourceTemplate = path.Join(job.tplSpec.SourceDir, job.tplSpec.RelativeSourcePath)
+		result.Item = job.item
+		result.File = job.file
+	}
+	return &result
+}
+
+// detectConflictingTargets makes sure every target path is written by at most one job.
+//
+// If a later job renders the same target path as an earlier one, the later job fails, unless its template sets
+// allow_override. In that case the earlier job is skipped instead.
+func (i *GeneratorImpl) detectConflictingTargets(jobs []*renderJob) {
+	writers := make(map[string]int)
+	for n, job := range jobs {
+		if job.result != nil || job.skipped {
+			continue
+		}
+		targetPath := path.Clean(job.targetPath)
+		if targetPath == "." {
+			// not a file at all, writing it will fail anyway
+			continue
+		}
+		earlier, seen := writers[targetPath]
+		if seen && !job.tplSpec.AllowOverride {
+			conflict := errorJob(job.targetPath, fmt.Errorf("target path %s is rendered by both %s and %s - set allow_override on the later template if this is intended",
+				job.targetPath, jobs[earlier].source(), job.source()))
+			conflict.tplSpec, conflict.item, conflict.file = job.tplSpec, job.item, job.file
+			conflict.startsTemplates = job.startsTemplates
+			jobs[n] = conflict
+			continue
+		}
+		if seen {
+			jobs[earlier].skipped = true
+		}
+		writers[targetPath] = n
+	}
+}
@@ -0,0 +1,286 @@
+package implementation
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/generatordir"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"sort"
+	"strings"
+)
+
+func (i *GeneratorImpl) MigrateRenderSpec(ctx context.Context, request *api.Request) *api.Response {
+	sourceDir := generatordir.Instance(ctx, request.SourceBaseDir)
+	targetDir := targetdir.Instance(ctx, request.TargetBaseDir)
+	specFile := targetDir.RenderSpecFilenameOrDefault(ctx, request.RenderSpecFile)
+
+	renderSpec, err := targetDir.ObtainRenderSpec(ctx, specFile)
+	if err != nil {
+		return i.errorResponseToplevel(ctx, err)
+	}
+
+	invocations, err := renderSpecInvocations(renderSpec)
+	if err != nil {
+		return i.errorResponseToplevel(ctx, err)
+	}
+
+	changes := []string{}
+	for n := range invocations {
+		invocation := &invocations[n]
+		invocationChanges, err := i.migrateInvocation(ctx, request, sourceDir, invocation)
+		if len(renderSpec.Generators) > 0 {
+			for c := range invocationChanges {
+				invocationChanges[c] = fmt.Sprintf("generator #%d (%s): %s", n+1, invocation.GeneratorName, invocationChanges[c])
+			}
+			if err != nil {
+				err = fmt.Errorf("error migrating generator #%d (%s): %s", n+1, invocation.GeneratorName, err)
+			}
+		}
+		if err != nil {
+			return i.errorResponseToplevel(ctx, err)
+		}
+		changes = append(changes, invocationChanges...)
+	}
+
+	result := i.successFileResult(ctx, specFile)
+	result.Action = api.FileUnchanged
+	if len(changes) > 0 {
+		result.Action = api.FileUpdated
+		if len(renderSpec.Generators) == 0 {
+			renderSpec.GeneratorVersion = invocations[0].GeneratorVersion
+			renderSpec.Parameters = invocations[0].Parameters
+			renderSpec.ParameterOrder = invocations[0].ParameterOrder
+			renderSpec.ParameterComments = invocations[0].ParameterComments
+			renderSpec.ParameterWarnings = invocations[0].ParameterWarnings
+		}
+		if !request.DryRun {
+			if _, err := targetDir.WriteRenderSpec(ctx, renderSpec, specFile); err != nil {
+				return i.errorResponseToplevel(ctx, err)
+			}
+		}
+	}
+
+	response := i.successResponse(ctx, []api.FileResult{result})
+	response.Changes = changes
+	return response
+}
+
+// migrateInvocation applies the pending migrations of a generator to its parameters, and records its current version.
+//
+// The parameters must be valid for the generator afterwards.
+func (i *GeneratorImpl) migrateInvocation(ctx context.Context, request *api.Request, sourceDir *generatordir.GeneratorDirectory, invocation *api.GeneratorInvocation) ([]string, error) {
+	genSpec, err := i.obtainGeneratorSpec(ctx, request, sourceDir, invocation.GeneratorName)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := i.applyMigrations(ctx, genSpec, invocation)
+	if err != nil {
+		return changes, err
+	}
+
+	if err := i.validateMigratedParameters(ctx, genSpec, invocation); err != nil {
+		return changes, err
+	}
+	invocation.ParameterComments, invocation.ParameterWarnings = parameterAnnotations(genSpec, invocation.Parameters)
+	return changes, nil
+}
+
+// applyMigrations applies the pending migrations of a generator to its parameters, and records its current version.
+func (i *GeneratorImpl) applyMigrations(ctx context.Context, genSpec *api.GeneratorSpec, invocation *api.GeneratorInvocation) ([]string, error) {
+	migrations, err := pendingMigrations(genSpec, invocation)
+	if err != nil {
+		return nil, err
+	}
+
+	if invocation.Parameters == nil {
+		invocation.Parameters = map[string]interface{}{}
+	}
+	invocation.ParameterOrder = genSpec.VariableOrder
+	changes := []string{}
+	for _, migration := range migrations {
+		for n, step := range migration.Steps {
+			change, err := i.applyMigrationStep(ctx, invocation.Parameters, &step, fmt.Sprintf("__migration_%s_%d", migration.Version, n+1))
+			if err != nil {
+				return changes, fmt.Errorf("step #%d of migration to version %s failed: %s", n+1, migration.Version, err)
+			}
+			if change != "" {
+				changes = append(changes, fmt.Sprintf("%s (migration to version %s)", change, migration.Version))
+			}
+		}
+	}
+
+	if genSpec.Version != "" && invocation.GeneratorVersion != genSpec.Version {
+		invocation.GeneratorVersion = genSpec.Version
+		changes = append(changes, fmt.Sprintf("recorded generator version %s", genSpec.Version))
+	}
+	return changes, nil
+}
+
+func (i *GeneratorImpl) validateMigratedParameters(ctx context.Context, genSpec *api.GeneratorSpec, invocation *api.GeneratorInvocation) error {
+	if _, err := i.constructAndValidateParameterMap(ctx, genSpec, &api.RenderSpec{
+		GeneratorName: invocation.GeneratorName,
+		Parameters:    invocation.Parameters,
+	}); err != nil {
+		return fmt.Errorf("render spec is still invalid after migration: %s", err)
+	}
+	if err := checkExtraneousParameters(genSpec, invocation.Parameters); err != nil {
+		return fmt.Errorf("render spec is still invalid after migration: %s", err)
+	}
+	return nil
+}
+
+// pendingMigrations returns the migrations of the generator that the render spec has not seen yet, ordered by version.
+func pendingMigrations(genSpec *api.GeneratorSpec, invocation *api.GeneratorInvocation) ([]api.MigrationSpec, error) {
+	recorded, err := semver.NewVersion("0.0.0")
+	if invocation.GeneratorVersion != "" {
+		recorded, err = semver.NewVersion(invocation.GeneratorVersion)
+		if err != nil {
+			return nil, fmt.Errorf("render spec was written for version '%s' of generator %s, which is not a valid semantic version", invocation.GeneratorVersion, invocation.GeneratorName)
+		}
+	}
+
+	var current *semver.Version
+	if genSpec.Version != "" {
+		// the generator spec has already been validated
+		current, _ = semver.NewVersion(genSpec.Version)
+		if recorded.GreaterThan(current) {
+			return nil, fmt.Errorf("render spec was written for version %s of generator %s, which is newer than its current version %s", invocation.GeneratorVersion, invocation.GeneratorName, genSpec.Version)
+		}
+	}
+
+	result := []api.MigrationSpec{}
+	for _, migration := range genSpec.Migrations {
+		version, _ := semver.NewVersion(migration.Version)
+		if version.GreaterThan(recorded) && (current == nil || !version.GreaterThan(current)) {
+			result = append(result, migration)
+		}
+	}
+	sort.SliceStable(result, func(a, b int) bool {
+		versionA, _ := semver.NewVersion(result[a].Version)
+		versionB, _ := semver.NewVersion(result[b].Version)
+		return versionA.LessThan(versionB)
+	})
+	return result, nil
+}
+
+// applyMigrationStep changes the parameters according to the step, and describes what it did.
+//
+// Returns the empty string if the step did not apply.
+func (i *GeneratorImpl) applyMigrationStep(ctx context.Context, parameters map[string]interface{}, step *api.MigrationStep, templateName string) (string, error) {
+	actions := 0
+	for _, name := range []string{step.Rename, step.Move, step.SetDefault, step.Drop, step.Transform} {
+		if name != "" {
+			actions++
+		}
+	}
+	if actions != 1 {
+		return "", fmt.Errorf("must specify exactly one of rename, move, set_default, drop, transform")
+	}
+
+	switch {
+	case step.Rename != "":
+		value, ok := parameters[step.Rename]
+		if step.To == "" {
+			return "", fmt.Errorf("rename of parameter '%s' requires to", step.Rename)
+		}
+		if !ok {
+			return "", nil
+		}
+		if _, exists := parameters[step.To]; exists {
+			return "", fmt.Errorf("cannot rename parameter '%s' to '%s', which already exists", step.Rename, step.To)
+		}
+		delete(parameters, step.Rename)
+		parameters[step.To] = value
+		return fmt.Sprintf("renamed parameter '%s' to '%s'", step.Rename, step.To), nil
+	case step.Move != "":
+		value, ok := parameters[step.Move]
+		if step.To == "" {
+			return "", fmt.Errorf("move of parameter '%s' requires to", step.Move)
+		}
+		if !ok {
+			return "", nil
+		}
+		delete(parameters, step.Move)
+		if err := setNestedParameter(parameters, strings.Split(step.To, "."), value); err != nil {
+			parameters[step.Move] = value
+			return "", fmt.Errorf("cannot move parameter '%s' to '%s': %s", step.Move, step.To, err)
+		}
+		return fmt.Sprintf("moved parameter '%s' to '%s'", step.Move, step.To), nil
+	case step.SetDefault != "":
+		if step.Value == nil {
+			return "", fmt.Errorf("set_default of parameter '%s' requires value", step.SetDefault)
+		}
+		if _, ok := parameters[step.SetDefault]; ok {
+			return "", nil
+		}
+		parameters[step.SetDefault] = step.Value
+		return fmt.Sprintf("set parameter '%s' to '%v'", step.SetDefault, step.Value), nil
+	case step.Drop != "":
+		if _, ok := parameters[step.Drop]; !ok {
+			return "", nil
+		}
+		delete(parameters, step.Drop)
+		return fmt.Sprintf("dropped parameter '%s'", step.Drop), nil
+	default:
+		value, ok := parameters[step.Transform]
+		if step.Template == "" {
+			return "", fmt.Errorf("transform of parameter '%s' requires template", step.Transform)
+		}
+		if !ok {
+			return "", nil
+		}
+		transformed, err := i.renderString(ctx, parameters, templateName, step.Template)
+		if err != nil {
+			return "", fmt.Errorf("error evaluating template '%s' for parameter '%s': %s", step.Template, step.Transform, err)
+		}
+		if transformed == fmt.Sprintf("%v", value) {
+			return "", nil
+		}
+		parameters[step.Transform] = transformed
+		return fmt.Sprintf("transformed parameter '%s' from '%v' to '%s'", step.Transform, value, transformed), nil
+	}
+}
+
+// setNestedParameter sets the value at the path of keys, creating maps as needed. The last key must not exist yet.
+//
+// Maps read from yaml have keys of type interface{}, so both kinds of maps are supported.
+func setNestedParameter(parameters map[string]interface{}, keys []string, value interface{}) error {
+	var current interface{} = parameters
+	for n, key := range keys {
+		last := n == len(keys)-1
+		var child interface{}
+		var exists bool
+		switch m := current.(type) {
+		case map[string]interface{}:
+			child, exists = m[key]
+			if !exists {
+				if last {
+					m[key] = value
+					return nil
+				}
+				child = map[string]interface{}{}
+				m[key] = child
+			}
+		case map[interface{}]interface{}:
+			child, exists = m[key]
+			if !exists {
+				if last {
+					m[key] = value
+					return nil
+				}
+				child = map[string]interface{}{}
+				m[key] = child
+			}
+		default:
+			return fmt.Errorf("'%s' is not a map", strings.Join(keys[:n], "."))
+		}
+		if last {
+			return fmt.Errorf("'%s' already exists", strings.Join(keys, "."))
+		}
+		current = child
+	}
+	return nil
+}
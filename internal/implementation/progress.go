@@ -0,0 +1,78 @@
+package implementation
+
+import (
+	"context"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"sync"
+)
+
+// progressTracker counts what happens during a Render call, and forwards events to the observer from the request.
+//
+// It serializes all observer callbacks, so it is safe to use from multiple goroutines.
+type progressTracker struct {
+	mutex    sync.Mutex
+	observer api.RenderObserver
+	progress api.RenderProgress
+}
+
+func newProgressTracker(observer api.RenderObserver) *progressTracker {
+	if observer == nil {
+		observer = api.NoopRenderObserver{}
+	}
+	return &progressTracker{observer: observer}
+}
+
+func (p *progressTracker) started(ctx context.Context, templatesTotal int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.progress.TemplatesTotal = templatesTotal
+	p.observer.OnRenderStart(ctx, p.progress)
+}
+
+func (p *progressTracker) templateStarted(ctx context.Context, tplSpec *api.TemplateSpec) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.progress.TemplatesStarted++
+	p.observer.OnTemplateStart(ctx, *tplSpec, p.progress)
+}
+
+// fileDone reports a file result and returns it unchanged.
+func (p *progressTracker) fileDone(ctx context.Context, result api.FileResult) api.FileResult {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if result.Success {
+		p.progress.FilesRendered++
+		p.observer.OnFileRendered(ctx, result, p.progress)
+	}
+	for _, err := range result.Errors {
+		p.progress.Errors++
+		p.observer.OnError(ctx, err, p.progress)
+	}
+	return result
+}
+
+func (p *progressTracker) fileSkipped(ctx context.Context, relativeFilePath string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.progress.FilesSkipped++
+	p.observer.OnFileSkipped(ctx, relativeFilePath, p.progress)
+}
+
+func (p *progressTracker) failed(ctx context.Context, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.progress.Errors++
+	p.observer.OnError(ctx, err, p.progress)
+}
+
+func (p *progressTracker) finished(ctx context.Context, response *api.Response) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.observer.OnRenderFinished(ctx, response, p.progress)
+}
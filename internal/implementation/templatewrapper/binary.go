@@ -0,0 +1,20 @@
+package templatewrapper
+
+import (
+	"bytes"
+)
+
+// only look at the beginning of large files, like git does
+const sniffLength = 8000
+
+// IsBinary guesses whether content is binary data rather than text, so it must not be parsed as a template.
+//
+// Like git, only a NUL byte counts as binary. Text in a legacy encoding like Latin-1 is not valid UTF-8,
+// but must still be rendered.
+func IsBinary(content []byte) bool {
+	sample := content
+	if len(sample) > sniffLength {
+		sample = sample[:sniffLength]
+	}
+	return bytes.IndexByte(sample, 0) >= 0
+}
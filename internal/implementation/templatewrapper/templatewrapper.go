@@ -11,6 +11,8 @@ type TemplateWrapper struct {
 	templateContent []byte
 	templateName    string
 	templatePath    string
+	leftDelimiter   string
+	rightDelimiter  string
 	tmpl            *template.Template
 }
 
@@ -25,6 +27,18 @@ func New(isRawFile bool, templateContent []byte, templateName string, templatePa
 	return t
 }
 
+// WithDelimiters sets custom action delimiters to use during Parse. Empty strings select the defaults.
+func (i *TemplateWrapper) WithDelimiters(leftDelimiter string, rightDelimiter string) *TemplateWrapper {
+	i.leftDelimiter = leftDelimiter
+	i.rightDelimiter = rightDelimiter
+	return i
+}
+
+// IsRawFile is true if the template content is copied verbatim rather than rendered.
+func (i *TemplateWrapper) IsRawFile() bool {
+	return i.isRawFile
+}
+
 func (i *TemplateWrapper) Write(wr io.Writer, name string, data interface{}) error {
 	if i.isRawFile {
 		_, err := wr.Write(i.templateContent)
@@ -36,7 +50,7 @@ func (i *TemplateWrapper) Write(wr io.Writer, name string, data interface{}) err
 
 func (i *TemplateWrapper) Parse() (*TemplateWrapper, error) {
 	if !i.isRawFile && i.tmpl == nil {
-		tmpl, err := template.New(i.templateName).Funcs(sprig.TxtFuncMap()).Parse(string(i.templateContent))
+		tmpl, err := template.New(i.templateName).Delims(i.leftDelimiter, i.rightDelimiter).Funcs(sprig.TxtFuncMap()).Parse(string(i.templateContent))
 		i.tmpl = tmpl
 		return i, err
 	}
@@ -0,0 +1,201 @@
+package implementation
+
+import (
+	"context"
+	"fmt"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/generatordir"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"path"
+	"sort"
+	"strings"
+)
+
+func (i *GeneratorImpl) Upgrade(ctx context.Context, request *api.Request) *api.Response {
+	progress := newProgressTracker(request.Observer)
+	response := i.upgrade(ctx, request, progress)
+	progress.finished(ctx, response)
+	return response
+}
+
+func (i *GeneratorImpl) upgrade(ctx context.Context, request *api.Request, progress *progressTracker) *api.Response {
+	sourceDir := generatordir.Instance(ctx, request.SourceBaseDir)
+	targetDir := targetdir.Instance(ctx, request.TargetBaseDir)
+	specFile := targetDir.RenderSpecFilenameOrDefault(ctx, request.RenderSpecFile)
+
+	renderSpec, err := targetDir.ObtainRenderSpec(ctx, specFile)
+	if err != nil {
+		return i.errorResponseToplevel(ctx, err)
+	}
+
+	invocations, err := renderSpecInvocations(renderSpec)
+	if err != nil {
+		return i.errorResponseToplevel(ctx, err)
+	}
+
+	changes := []string{}
+	defaulted := []string{}
+	missing := []string{}
+	for n := range invocations {
+		invocation := &invocations[n]
+		prefix := ""
+		if len(renderSpec.Generators) > 0 {
+			prefix = fmt.Sprintf("generator #%d (%s): ", n+1, invocation.GeneratorName)
+		}
+
+		invocationChanges, invocationDefaulted, invocationMissing, err := i.upgradeInvocation(ctx, request, sourceDir, invocation)
+		changes = append(changes, withPrefix(prefix, invocationChanges)...)
+		defaulted = append(defaulted, withPrefix(prefix, invocationDefaulted)...)
+		missing = append(missing, withPrefix(prefix, invocationMissing)...)
+		if err != nil {
+			if prefix != "" {
+				err = fmt.Errorf("error upgrading %s%s", prefix, err)
+			}
+			response := i.errorResponseToplevel(ctx, err)
+			response.Changes = changes
+			return response
+		}
+	}
+	if len(missing) > 0 {
+		response := i.errorResponseToplevel(ctx, fmt.Errorf("new parameters require a value, nothing was rendered: %s", strings.Join(missing, ", ")))
+		response.Changes = changes
+		response.DefaultedParameters = defaulted
+		response.MissingParameters = missing
+		return response
+	}
+	if len(renderSpec.Generators) == 0 {
+		renderSpec.GeneratorVersion = invocations[0].GeneratorVersion
+		renderSpec.Parameters = invocations[0].Parameters
+		renderSpec.ParameterOrder = invocations[0].ParameterOrder
+		renderSpec.ParameterComments = invocations[0].ParameterComments
+		renderSpec.ParameterWarnings = invocations[0].ParameterWarnings
+	}
+
+	response := i.renderWithSpec(ctx, request, progress, sourceDir, targetDir, renderSpec)
+	response.Changes = changes
+	response.DefaultedParameters = defaulted
+	if !response.Success {
+		// keep the render spec as it was, so the upgrade can simply be repeated
+		return response
+	}
+
+	renderedFiles := renderedFileList(response)
+	rendered := make(map[string]bool)
+	for _, relativePath := range renderedFiles {
+		rendered[relativePath] = true
+	}
+	for _, previous := range renderSpec.RenderedFiles {
+		if rendered[path.Clean(previous)] {
+			continue
+		}
+		result, deleted := i.deleteRemovedFile(ctx, request, targetDir, previous)
+		if !deleted {
+			continue
+		}
+		response.RenderedFiles = append(response.RenderedFiles, result)
+		if !result.Success {
+			response.Success = false
+			response.Errors = append(response.Errors, fmt.Errorf("failed to delete %s, which is no longer rendered", previous))
+		}
+	}
+
+	renderSpec.RenderedFiles = renderedFiles
+	if !request.DryRun {
+		if _, err := targetDir.WriteRenderSpec(ctx, renderSpec, specFile); err != nil {
+			response.Success = false
+			response.Errors = append(response.Errors, err)
+		}
+	}
+	return response
+}
+
+// renderedFileList lists the files in the response that were written or would have been written, in order.
+func renderedFileList(response *api.Response) []string {
+	renderedFiles := []string{}
+	rendered := make(map[string]bool)
+	for _, result := range response.RenderedFiles {
+		relativePath := path.Clean(result.RelativeFilePath)
+		if result.Action != api.FileSkipped && result.Action != api.FileDeleted && !rendered[relativePath] {
+			renderedFiles = append(renderedFiles, relativePath)
+			rendered[relativePath] = true
+		}
+	}
+	return renderedFiles
+}
+
+// upgradeInvocation applies migrations and sets new variables to their defaults, and reports the names of
+// the variables that were set, and of those that need a value because they have no default.
+func (i *GeneratorImpl) upgradeInvocation(ctx context.Context, request *api.Request, sourceDir *generatordir.GeneratorDirectory, invocation *api.GeneratorInvocation) ([]string, []string, []string, error) {
+	genSpec, err := i.obtainGeneratorSpec(ctx, request, sourceDir, invocation.GeneratorName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	changes, err := i.applyMigrations(ctx, genSpec, invocation)
+	if err != nil {
+		return changes, nil, nil, err
+	}
+
+	names := make([]string, 0, len(genSpec.Variables))
+	for name := range genSpec.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defaulted := []string{}
+	missing := []string{}
+	for _, name := range names {
+		if _, ok := invocation.Parameters[name]; ok {
+			continue
+		}
+		defaultValue := genSpec.Variables[name].DefaultValue
+		if defaultValue == nil {
+			missing = append(missing, name)
+			continue
+		}
+		if defaultStr, ok := defaultValue.(string); ok {
+			defaultValue, err = i.renderStringDefaultFromTemplate(name, defaultStr)
+			if err != nil {
+				return changes, defaulted, missing, err
+			}
+		}
+		invocation.Parameters[name] = defaultValue
+		defaulted = append(defaulted, name)
+	}
+	if len(missing) > 0 {
+		return changes, defaulted, missing, nil
+	}
+	invocation.ParameterComments, invocation.ParameterWarnings = parameterAnnotations(genSpec, invocation.Parameters)
+
+	return changes, defaulted, missing, i.validateMigratedParameters(ctx, genSpec, invocation)
+}
+
+// deleteRemovedFile deletes a file that the previous upgrade rendered, but the current one did not.
+//
+// Returns false if there was no such file, so there is nothing to report.
+func (i *GeneratorImpl) deleteRemovedFile(ctx context.Context, request *api.Request, targetDir *targetdir.TargetDirectory, relativePath string) (api.FileResult, bool) {
+	cleanPath := path.Clean(relativePath)
+	if cleanPath == ".." || strings.HasPrefix(cleanPath, "../") || path.IsAbs(cleanPath) {
+		return i.errorFileResult(ctx, relativePath, fmt.Errorf("rendered file %s is not inside the target directory - not deleting it", relativePath)), true
+	}
+	if !targetDir.FileExists(ctx, cleanPath) {
+		return api.FileResult{}, false
+	}
+
+	if !request.DryRun {
+		if err := targetDir.DeleteFile(ctx, cleanPath); err != nil {
+			return i.errorFileResult(ctx, cleanPath, fmt.Errorf("error deleting %s: %s", cleanPath, err)), true
+		}
+	}
+	result := i.successFileResult(ctx, cleanPath)
+	result.Action = api.FileDeleted
+	return result, true
+}
+
+func withPrefix(prefix string, values []string) []string {
+	result := make([]string, len(values))
+	for n, value := range values {
+		result[n] = prefix + value
+	}
+	return result
+}
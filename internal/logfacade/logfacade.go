@@ -20,6 +20,24 @@ func (i *GeneratorLogfacade) FindGeneratorNames(ctx context.Context, sourceBaseD
 	return result, err
 }
 
+func (i *GeneratorLogfacade) ListGenerators(ctx context.Context, sourceBaseDir string) ([]api.GeneratorInfo, error) {
+	aulogging.Logger.Ctx(ctx).Debug().Printf("entering ListGenerators sourceBaseDir=%s", sourceBaseDir)
+	result, err := i.Wrapped.ListGenerators(ctx, sourceBaseDir)
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Warn().WithErr(err).Print("error in ListGenerators")
+	}
+	return result, err
+}
+
+func (i *GeneratorLogfacade) BuildCatalog(ctx context.Context, baseDirs []string) (*api.Catalog, error) {
+	aulogging.Logger.Ctx(ctx).Debug().Printf("entering BuildCatalog baseDirs=%v", baseDirs)
+	result, err := i.Wrapped.BuildCatalog(ctx, baseDirs)
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Warn().WithErr(err).Print("error in BuildCatalog")
+	}
+	return result, err
+}
+
 func (i *GeneratorLogfacade) ObtainGeneratorSpec(ctx context.Context, sourceBaseDir string, generatorName string) (*api.GeneratorSpec, error) {
 	aulogging.Logger.Ctx(ctx).Debug().Printf("entering ObtainGeneratorSpec sourceBaseDir=%s generatorName=%s", sourceBaseDir, generatorName)
 	result, err := i.Wrapped.ObtainGeneratorSpec(ctx, sourceBaseDir, generatorName)
@@ -65,5 +83,55 @@ func (i *GeneratorLogfacade) Render(ctx context.Context, request *api.Request) *
 			aulogging.Logger.Ctx(ctx).Debug().Printf("%s %s", "OK", f.RelativeFilePath)
 		}
 	}
+	for _, h := range result.HookResults {
+		if len(h.Errors) > 0 || !h.Success {
+			aulogging.Logger.Ctx(ctx).Warn().Printf("%s hook %v exit code %d: %s", "ERR", h.Command, h.ExitCode, h.Errors[0].Error())
+		} else {
+			aulogging.Logger.Ctx(ctx).Info().Printf("%s hook %v", "OK", h.Command)
+		}
+	}
+	for _, w := range result.Warnings {
+		aulogging.Logger.Ctx(ctx).Warn().Printf("warning in Render: %s", w)
+	}
+	return result
+}
+
+func (i *GeneratorLogfacade) MigrateRenderSpec(ctx context.Context, request *api.Request) *api.Response {
+	aulogging.Logger.Ctx(ctx).Debug().Printf("entering MigrateRenderSpec sourceBaseDir=%s targetBaseDir=%s renderSpecFile=%s", request.SourceBaseDir, request.TargetBaseDir, request.RenderSpecFile)
+	result := i.Wrapped.MigrateRenderSpec(ctx, request)
+	if len(result.Errors) > 0 {
+		aulogging.Logger.Ctx(ctx).Warn().WithErr(result.Errors[0]).Printf("%d error(s) in MigrateRenderSpec: first error was %s", len(result.Errors), result.Errors[0].Error())
+	}
+	for _, c := range result.Changes {
+		aulogging.Logger.Ctx(ctx).Info().Printf("migrated render spec: %s", c)
+	}
+	return result
+}
+
+func (i *GeneratorLogfacade) Upgrade(ctx context.Context, request *api.Request) *api.Response {
+	aulogging.Logger.Ctx(ctx).Debug().Printf("entering Upgrade sourceBaseDir=%s targetBaseDir=%s renderSpecFile=%s", request.SourceBaseDir, request.TargetBaseDir, request.RenderSpecFile)
+	result := i.Wrapped.Upgrade(ctx, request)
+	if len(result.Errors) > 0 {
+		aulogging.Logger.Ctx(ctx).Warn().WithErr(result.Errors[0]).Printf("%d error(s) in Upgrade: first error was %s", len(result.Errors), result.Errors[0].Error())
+	}
+	for _, c := range result.Changes {
+		aulogging.Logger.Ctx(ctx).Info().Printf("migrated render spec: %s", c)
+	}
+	for _, p := range result.DefaultedParameters {
+		aulogging.Logger.Ctx(ctx).Info().Printf("new parameter %s set to its default value", p)
+	}
+	for _, p := range result.MissingParameters {
+		aulogging.Logger.Ctx(ctx).Warn().Printf("new parameter %s requires a value", p)
+	}
+	for _, f := range result.RenderedFiles {
+		if len(f.Errors) > 0 {
+			aulogging.Logger.Ctx(ctx).Warn().Printf("%s %s: %s", "ERR", f.RelativeFilePath, f.Errors[0].Error())
+		} else {
+			aulogging.Logger.Ctx(ctx).Info().Printf("%s %s %s", "OK", f.RelativeFilePath, f.Action)
+		}
+	}
+	for _, w := range result.Warnings {
+		aulogging.Logger.Ctx(ctx).Warn().Printf("warning in Upgrade: %s", w)
+	}
 	return result
 }
@@ -0,0 +1,116 @@
+package generatordir
+
+import (
+	"context"
+	"fmt"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BuildCatalog finds all generators in the base directories and all their subdirectories.
+//
+// Hidden directories are skipped.
+func BuildCatalog(ctx context.Context, baseDirs []string) (*api.Catalog, error) {
+	prefixes, err := catalogPrefixes(baseDirs)
+	if err != nil {
+		return &api.Catalog{}, err
+	}
+
+	catalog := &api.Catalog{Entries: []api.CatalogEntry{}}
+	qualifiedNames := make(map[string]string)
+	for n, baseDir := range baseDirs {
+		prefix := prefixes[n]
+		if err := Instance(ctx, baseDir).CheckValid(ctx); err != nil {
+			return &api.Catalog{}, err
+		}
+
+		err := filepath.Walk(baseDir, func(dir string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				return nil
+			}
+			if dir != baseDir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			relativeDir, err := filepath.Rel(baseDir, dir)
+			if err != nil {
+				return err
+			}
+			generators, err := Instance(ctx, dir).ListGenerators(ctx)
+			if err != nil {
+				return fmt.Errorf("error reading generators in %s: %s", dir, err.Error())
+			}
+			for _, generator := range generators {
+				qualifiedName := path.Join(prefix, filepath.ToSlash(relativeDir), generator.Name)
+				if other, ok := qualifiedNames[qualifiedName]; ok {
+					return fmt.Errorf("qualified generator name %s is not unique, found in %s and %s", qualifiedName, other, dir)
+				}
+				qualifiedNames[qualifiedName] = dir
+				catalog.Entries = append(catalog.Entries, api.CatalogEntry{
+					QualifiedName: qualifiedName,
+					SourceBaseDir: dir,
+					Info:          generator,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return &api.Catalog{}, err
+		}
+	}
+
+	sort.Slice(catalog.Entries, func(a, b int) bool {
+		return catalog.Entries[a].QualifiedName < catalog.Entries[b].QualifiedName
+	})
+	return catalog, nil
+}
+
+// catalogPrefixes returns the prefix of the qualified names for each base directory. This is the name of the
+// base directory, extended by the names of its parent directories until it differs from the prefixes of
+// all other base directories, e.g. 'a/generators' and 'b/generators' for '/a/generators' and '/b/generators'.
+func catalogPrefixes(baseDirs []string) ([]string, error) {
+	segments := make([][]string, len(baseDirs))
+	for n, baseDir := range baseDirs {
+		absoluteDir, err := filepath.Abs(baseDir)
+		if err != nil {
+			return []string{}, fmt.Errorf("error resolving generator directory %s: %s", baseDir, err.Error())
+		}
+		segments[n] = strings.Split(strings.Trim(filepath.ToSlash(absoluteDir), "/"), "/")
+	}
+
+	prefixes := make([]string, len(baseDirs))
+	for n := range segments {
+		length := 1
+		for length < len(segments[n]) && !uniqueSuffix(segments, n, length) {
+			length++
+		}
+		prefixes[n] = strings.Join(segments[n][len(segments[n])-length:], "/")
+	}
+	return prefixes, nil
+}
+
+// uniqueSuffix is true if the last length segments of segments[n] differ from those of all other
+// directories. The same directory given twice is ignored here, it is reported as a duplicate later.
+func uniqueSuffix(segments [][]string, n int, length int) bool {
+	suffix := strings.Join(segments[n][len(segments[n])-length:], "/")
+	for other := range segments {
+		if strings.Join(segments[other], "/") == strings.Join(segments[n], "/") {
+			continue
+		}
+		otherLength := length
+		if otherLength > len(segments[other]) {
+			otherLength = len(segments[other])
+		}
+		if strings.Join(segments[other][len(segments[other])-otherLength:], "/") == suffix {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package generatordir
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/semver"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// obtainExtendedGeneratorSpec reads the spec of a generator and resolves its extends chain.
+//
+// chain lists the generators that are currently being resolved, including their directories, to detect cycles.
+func (d *GeneratorDirectory) obtainExtendedGeneratorSpec(ctx context.Context, generatorName string, chain []string) (*api.GeneratorSpec, error) {
+	key := path.Join(path.Clean(d.baseDir), generatorName)
+	for _, active := range chain {
+		if active == key {
+			return &api.GeneratorSpec{}, fmt.Errorf("extends cycle detected: %s", describeExtendsChain(append(chain, key)))
+		}
+	}
+
+	spec, err := d.obtainGeneratorSpecFile(ctx, generatorName)
+	if err != nil || spec.Extends == "" {
+		return spec, err
+	}
+
+	base, err := d.Relative(ctx, spec.ExtendsSourceDir).obtainExtendedGeneratorSpec(ctx, spec.Extends, append(chain, key))
+	if err == nil && len(base.Migrations) > 0 {
+		// render specs record the version of the extending generator, which says nothing about the base generator
+		base, err = &api.GeneratorSpec{}, fmt.Errorf("base generator %s has migrations, which cannot be inherited", spec.Extends)
+	}
+	if err != nil {
+		if len(chain) > 0 {
+			// already has the context
+			return base, err
+		}
+		return base, fmt.Errorf("error resolving base generator %s of %s: %s", spec.Extends, generatorName, err.Error())
+	}
+	return mergeGeneratorSpecs(base, spec, spec.ExtendsSourceDir), nil
+}
+
+// describeExtendsChain lists the generators in the chain, with their directories relative to the first one.
+func describeExtendsChain(chain []string) string {
+	firstDir := path.Dir(chain[0])
+	names := make([]string, 0, len(chain))
+	for _, key := range chain {
+		if relative, err := filepath.Rel(firstDir, key); err == nil {
+			key = filepath.ToSlash(relative)
+		}
+		names = append(names, key)
+	}
+	return strings.Join(names, " -> ")
+}
+
+// relativeToBase converts a directory relative to the base generator to one relative to the extending generator.
+func relativeToBase(baseDir string, dir string) string {
+	if baseDir == "" || filepath.IsAbs(dir) {
+		return dir
+	}
+	return path.Join(baseDir, dir)
+}
+
+// mergeGeneratorSpecs returns the spec that results from spec extending base, which is in baseDir relative to spec.
+func mergeGeneratorSpecs(base *api.GeneratorSpec, spec *api.GeneratorSpec, baseDir string) *api.GeneratorSpec {
+	result := *spec
+
+	if baseDir != "" {
+		// keep the templates and includes of the base generator relative to its own directory
+		baseCopy := *base
+		baseCopy.Templates = make([]api.TemplateSpec, len(base.Templates))
+		for n, template := range base.Templates {
+			template.SourceDir = relativeToBase(baseDir, template.SourceDir)
+			baseCopy.Templates[n] = template
+		}
+		baseCopy.Includes = make([]api.IncludeSpec, len(base.Includes))
+		for n, include := range base.Includes {
+			include.SourceDir = relativeToBase(baseDir, include.SourceDir)
+			baseCopy.Includes[n] = include
+		}
+		base = &baseCopy
+	}
+
+	result.Templates = make([]api.TemplateSpec, 0, len(base.Templates)+len(spec.Templates))
+	replaced := make(map[int]bool)
+	for _, baseTemplate := range base.Templates {
+		replacement, n := findTemplateByTarget(spec.Templates, baseTemplate.RelativeTargetPath)
+		if replacement == nil {
+			result.Templates = append(result.Templates, baseTemplate)
+		} else if !replaced[n] {
+			// all base templates for the same target are replaced, the replacement takes the place of the first one
+			result.Templates = append(result.Templates, *replacement)
+			replaced[n] = true
+		}
+	}
+	for n, template := range spec.Templates {
+		if !replaced[n] {
+			result.Templates = append(result.Templates, template)
+		}
+	}
+
+	result.VariableOrder = append([]string{}, base.VariableOrder...)
+	for _, name := range spec.VariableOrder {
+		if _, ok := base.Variables[name]; !ok {
+			result.VariableOrder = append(result.VariableOrder, name)
+		}
+	}
+
+	result.Variables = make(map[string]api.VariableSpec)
+	for name, variable := range base.Variables {
+		result.Variables[name] = variable
+	}
+	for name, variable := range spec.Variables {
+		merged := result.Variables[name]
+		if variable.Description != "" {
+			merged.Description = variable.Description
+		}
+		if variable.ValidationPattern != "" {
+			merged.ValidationPattern = variable.ValidationPattern
+		}
+		if variable.DefaultValue != nil {
+			merged.DefaultValue = variable.DefaultValue
+		}
+		if variable.Label != "" {
+			merged.Label = variable.Label
+		}
+		if variable.Group != "" {
+			merged.Group = variable.Group
+		}
+		if variable.Example != "" {
+			merged.Example = variable.Example
+		}
+		if variable.Help != "" {
+			merged.Help = variable.Help
+		}
+		merged.Advanced = merged.Advanced || variable.Advanced
+		result.Variables[name] = merged
+	}
+
+	if spec.LeftDelimiter == "" && spec.RightDelimiter == "" {
+		result.LeftDelimiter = base.LeftDelimiter
+		result.RightDelimiter = base.RightDelimiter
+	}
+	if spec.Format == "" {
+		result.Format = base.Format
+	}
+	if spec.MinLibraryVersion == "" || (base.MinLibraryVersion != "" && semver.MustParse(base.MinLibraryVersion).GreaterThan(semver.MustParse(spec.MinLibraryVersion))) {
+		// both versions have already been validated, and the base generator's requirements still apply
+		result.MinLibraryVersion = base.MinLibraryVersion
+	}
+	if len(base.PreRenderHooks) > 0 {
+		result.PreRenderHooks = append(append([]api.HookSpec{}, base.PreRenderHooks...), spec.PreRenderHooks...)
+	}
+	if len(base.PostRenderHooks) > 0 {
+		result.PostRenderHooks = append(append([]api.HookSpec{}, base.PostRenderHooks...), spec.PostRenderHooks...)
+	}
+	if len(base.BinaryExtensions) > 0 {
+		result.BinaryExtensions = append(append([]string{}, base.BinaryExtensions...), spec.BinaryExtensions...)
+	}
+	if len(base.TextExtensions) > 0 {
+		result.TextExtensions = append(append([]string{}, base.TextExtensions...), spec.TextExtensions...)
+	}
+	if len(base.Includes) > 0 {
+		result.Includes = append(append([]api.IncludeSpec{}, base.Includes...), spec.Includes...)
+	}
+	return &result
+}
+
+func findTemplateByTarget(templates []api.TemplateSpec, relativeTargetPath string) (*api.TemplateSpec, int) {
+	for n := range templates {
+		if templates[n].RelativeTargetPath == relativeTargetPath {
+			return &templates[n], n
+		}
+	}
+	return nil, -1
+}
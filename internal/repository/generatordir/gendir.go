@@ -3,6 +3,7 @@ package generatordir
 import (
 	"context"
 	"fmt"
+	"github.com/Masterminds/semver"
 	"github.com/StephanHCB/go-generator-lib/api"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
@@ -22,6 +23,23 @@ func Instance(_ context.Context, baseDir string) *GeneratorDirectory {
 	return &GeneratorDirectory{baseDir: baseDir}
 }
 
+// BaseDir is the directory this generator directory was created for.
+func (d *GeneratorDirectory) BaseDir() string {
+	return d.baseDir
+}
+
+// Relative returns the generator directory at the given path, which is relative to this generator directory
+// unless it is absolute. An empty path returns this generator directory.
+func (d *GeneratorDirectory) Relative(ctx context.Context, relativeDir string) *GeneratorDirectory {
+	if relativeDir == "" {
+		return d
+	}
+	if filepath.IsAbs(relativeDir) {
+		return Instance(ctx, relativeDir)
+	}
+	return Instance(ctx, path.Join(d.baseDir, relativeDir))
+}
+
 func (d *GeneratorDirectory) CheckValid(_ context.Context) error {
 	if strings.HasSuffix(d.baseDir, "/") || strings.HasSuffix(d.baseDir, "\\") {
 		return fmt.Errorf("invalid generator directory: baseDir %s must not contain trailing slash", d.baseDir)
@@ -63,7 +81,55 @@ func (d *GeneratorDirectory) FindGeneratorNames(ctx context.Context) ([]string,
 	return result, nil
 }
 
+// ObtainGeneratorSpec reads the spec of a generator, with any generators it extends merged in.
+//
+// Fails if the generator needs a newer version of this library.
 func (d *GeneratorDirectory) ObtainGeneratorSpec(ctx context.Context, generatorName string) (*api.GeneratorSpec, error) {
+	spec, err := d.obtainExtendedGeneratorSpec(ctx, generatorName, []string{})
+	if err != nil {
+		return spec, err
+	}
+	if err := checkLibraryVersion(spec); err != nil {
+		return &api.GeneratorSpec{}, fmt.Errorf("cannot use generator %s: %s", generatorName, err.Error())
+	}
+	return spec, nil
+}
+
+// ListGenerators reads the metadata of all generators, sorted by name.
+//
+// Generators whose spec cannot be read are listed with the error instead of their metadata.
+func (d *GeneratorDirectory) ListGenerators(ctx context.Context) ([]api.GeneratorInfo, error) {
+	names, err := d.FindGeneratorNames(ctx)
+	if err != nil {
+		return []api.GeneratorInfo{}, err
+	}
+
+	result := []api.GeneratorInfo{}
+	for _, name := range names {
+		spec, err := d.obtainExtendedGeneratorSpec(ctx, name, []string{})
+		if err != nil {
+			result = append(result, api.GeneratorInfo{
+				Name:  name,
+				Error: err.Error(),
+			})
+			continue
+		}
+		result = append(result, api.GeneratorInfo{
+			Name:              name,
+			Version:           spec.Version,
+			Title:             spec.Title,
+			Description:       spec.Description,
+			Tags:              spec.Tags,
+			Owners:            spec.Owners,
+			DocsURL:           spec.DocsURL,
+			MinLibraryVersion: spec.MinLibraryVersion,
+			Compatible:        checkLibraryVersion(spec) == nil,
+		})
+	}
+	return result, nil
+}
+
+func (d *GeneratorDirectory) obtainGeneratorSpecFile(ctx context.Context, generatorName string) (*api.GeneratorSpec, error) {
 	if err := d.CheckValid(ctx); err != nil {
 		return &api.GeneratorSpec{}, err
 	}
@@ -78,9 +144,54 @@ func (d *GeneratorDirectory) ObtainGeneratorSpec(ctx context.Context, generatorN
 	if err != nil {
 		return &api.GeneratorSpec{}, fmt.Errorf("error parsing generator spec from file %s: %s", fileName, err.Error())
 	}
+	if err := d.validateVersions(ctx, generatorSpec); err != nil {
+		return &api.GeneratorSpec{}, fmt.Errorf("error parsing generator spec from file %s: %s", fileName, err.Error())
+	}
 	return generatorSpec, nil
 }
 
+func (d *GeneratorDirectory) validateVersions(_ context.Context, spec *api.GeneratorSpec) error {
+	if spec.Version != "" {
+		if _, err := semver.NewVersion(spec.Version); err != nil {
+			return fmt.Errorf("version '%s' is not a valid semantic version: %s", spec.Version, err.Error())
+		}
+	}
+	if spec.MinLibraryVersion != "" {
+		if _, err := semver.NewVersion(spec.MinLibraryVersion); err != nil {
+			return fmt.Errorf("min_library_version '%s' is not a valid semantic version: %s", spec.MinLibraryVersion, err.Error())
+		}
+	}
+	if len(spec.Migrations) > 0 && spec.Version == "" {
+		// render specs could not record which migrations they have seen, so all of them would be applied every time
+		return fmt.Errorf("migrations need a version of the generator")
+	}
+	for n, migration := range spec.Migrations {
+		version, err := semver.NewVersion(migration.Version)
+		if err != nil {
+			return fmt.Errorf("version '%s' of migration #%d is not a valid semantic version: %s", migration.Version, n+1, err.Error())
+		}
+		// validated above
+		current, _ := semver.NewVersion(spec.Version)
+		if version.GreaterThan(current) {
+			return fmt.Errorf("version '%s' of migration #%d is newer than the version '%s' of the generator", migration.Version, n+1, spec.Version)
+		}
+	}
+	return nil
+}
+
+func checkLibraryVersion(spec *api.GeneratorSpec) error {
+	if spec.MinLibraryVersion == "" {
+		return nil
+	}
+	// both versions have already been validated
+	required, _ := semver.NewVersion(spec.MinLibraryVersion)
+	current, _ := semver.NewVersion(api.LibraryVersion)
+	if current.LessThan(required) {
+		return fmt.Errorf("it requires version %s of go-generator-lib, but this is version %s", spec.MinLibraryVersion, api.LibraryVersion)
+	}
+	return nil
+}
+
 // --- public low level methods ---
 
 func (d *GeneratorDirectory) ReadFile(ctx context.Context, relativePath string) ([]byte, error) {
@@ -96,12 +207,36 @@ func (d *GeneratorDirectory) ReadFile(ctx context.Context, relativePath string)
 	return bytes, nil
 }
 
-func (d *GeneratorDirectory) Glob(ctx context.Context, relativeGlob string) ([]string, error) {
+func (d *GeneratorDirectory) FileMode(ctx context.Context, relativePath string) (os.FileMode, error) {
+	if err := d.CheckValid(ctx); err != nil {
+		return 0, err
+	}
+
+	fileInfo, err := os.Stat(path.Join(d.baseDir, relativePath))
+	if err != nil {
+		return 0, err
+	}
+
+	return fileInfo.Mode().Perm(), nil
+}
+
+// Glob returns the relative paths of all files matching relativeGlob.
+//
+// If the pattern contains a '**' path segment, it matches any number of directories, and only regular files are
+// returned. Such patterns skip hidden files and directories unless includeHidden is set or the pattern explicitly
+// names them (e.g. '.github/**'). Patterns without '**' behave exactly like filepath.Glob.
+func (d *GeneratorDirectory) Glob(ctx context.Context, relativeGlob string, includeHidden bool) ([]string, error) {
 	if err := d.CheckValid(ctx); err != nil {
 		return []string{}, err
 	}
 
-	filenames, err := filepath.Glob(path.Join(d.baseDir, relativeGlob))
+	var filenames []string
+	var err error
+	if IsRecursiveGlob(relativeGlob) {
+		filenames, err = d.recursiveGlob(relativeGlob, includeHidden)
+	} else {
+		filenames, err = filepath.Glob(path.Join(d.baseDir, relativeGlob))
+	}
 	if err != nil {
 		return []string{}, err
 	}
@@ -126,6 +261,44 @@ func (d *GeneratorDirectory) Glob(ctx context.Context, relativeGlob string) ([]s
 	return relativeFilenames, nil
 }
 
+// ListFiles returns the relative paths of all regular files below relativeDir, including hidden files, in sorted order.
+func (d *GeneratorDirectory) ListFiles(ctx context.Context, relativeDir string) ([]string, error) {
+	if err := d.CheckValid(ctx); err != nil {
+		return []string{}, err
+	}
+
+	startDir := path.Join(d.baseDir, relativeDir)
+	if rel, err := filepath.Rel(d.baseDir, startDir); err != nil || rel == ".." || strings.HasPrefix(strings.ReplaceAll(rel, `\`, `/`), "../") {
+		return []string{}, fmt.Errorf("directory %s is not inside base directory %s - this is forbidden", relativeDir, d.baseDir)
+	}
+
+	relativeFilenames := make([]string, 0)
+	err := filepath.Walk(startDir, func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(d.baseDir, fn)
+		if err != nil {
+			// unreachable as far as I'm aware
+			return err
+		}
+
+		relativeFilenames = append(relativeFilenames, strings.ReplaceAll(rel, `\`, `/`)) // sanitize under Windows
+		return nil
+	})
+	if err != nil {
+		return []string{}, err
+	}
+
+	sort.Strings(relativeFilenames)
+
+	return relativeFilenames, nil
+}
+
 // --- helper methods ---
 
 func (d *GeneratorDirectory) parseGenSpec(_ context.Context, specYaml []byte) (*api.GeneratorSpec, error) {
@@ -134,5 +307,17 @@ func (d *GeneratorDirectory) parseGenSpec(_ context.Context, specYaml []byte) (*
 	if err != nil {
 		return &api.GeneratorSpec{}, err
 	}
+
+	// a map loses the order of the variables, so read them again, this time in order
+	ordered := struct {
+		Variables yaml.MapSlice `yaml:"variables"`
+	}{}
+	if err := yaml.Unmarshal(specYaml, &ordered); err != nil {
+		// unreachable, the same yaml was just parsed successfully
+		return &api.GeneratorSpec{}, err
+	}
+	for _, item := range ordered.Variables {
+		spec.VariableOrder = append(spec.VariableOrder, fmt.Sprintf("%v", item.Key))
+	}
 	return spec, nil
 }
@@ -10,7 +10,7 @@ func TestGlobInvalid(t *testing.T) {
 	ctx := context.TODO()
 	cut := Instance(ctx, "../../../test/resources/valid-generator-simple")
 
-	actual, err := cut.Glob(ctx, "[]a]")
+	actual, err := cut.Glob(ctx, "[]a]", false)
 	require.Empty(t, actual)
 	require.NotNil(t, err)
 	require.Equal(t, "syntax error in pattern", err.Error())
@@ -21,8 +21,92 @@ func TestGlobForbidden(t *testing.T) {
 	cut := Instance(ctx, "../../../test/resources/valid-generator-simple")
 
 	// this could be ../../../etc/passwd
-	actual, err := cut.Glob(ctx, "src/sub/../../../valid-generator-structured/*.tmpl")
+	actual, err := cut.Glob(ctx, "src/sub/../../../valid-generator-structured/*.tmpl", false)
 	require.Empty(t, actual)
 	require.NotNil(t, err)
 	require.Equal(t, "file glob src/sub/../../../valid-generator-structured/*.tmpl leads to file that is not inside base directory ../../../test/resources/valid-generator-simple - this is forbidden", err.Error())
 }
+
+func TestListFilesForbidden(t *testing.T) {
+	ctx := context.TODO()
+	cut := Instance(ctx, "../../../test/resources/valid-generator-simple")
+
+	actual, err := cut.ListFiles(ctx, "src/../../valid-generator-structured")
+	require.Empty(t, actual)
+	require.NotNil(t, err)
+	require.Equal(t, "directory src/../../valid-generator-structured is not inside base directory ../../../test/resources/valid-generator-simple - this is forbidden", err.Error())
+}
+
+func TestGlobRecursive(t *testing.T) {
+	ctx := context.TODO()
+	cut := Instance(ctx, "../../../test/resources/valid-generator-simple")
+
+	actual, err := cut.Glob(ctx, "globs/**/*.tmpl", false)
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		"globs/sub/deeper/bottom.txt.tmpl",
+		"globs/sub/deeper/skip.txt.tmpl",
+		"globs/sub/middle.txt.tmpl",
+		"globs/top.txt.tmpl",
+	}, actual)
+}
+
+func TestGlobRecursiveHidden(t *testing.T) {
+	ctx := context.TODO()
+	cut := Instance(ctx, "../../../test/resources/valid-generator-simple")
+
+	actual, err := cut.Glob(ctx, "globs/**/secret.*", true)
+	require.Nil(t, err)
+	require.Equal(t, []string{"globs/.hidden/secret.txt.tmpl"}, actual)
+
+	actual, err = cut.Glob(ctx, "globs/.hidden/**", false)
+	require.Nil(t, err)
+	require.Equal(t, []string{"globs/.hidden/secret.txt.tmpl"}, actual)
+}
+
+func TestGlobRecursiveForbidden(t *testing.T) {
+	ctx := context.TODO()
+	cut := Instance(ctx, "../../../test/resources/valid-generator-simple")
+
+	actual, err := cut.Glob(ctx, "src/../../valid-generator-structured/**/*.tmpl", false)
+	require.Empty(t, actual)
+	require.NotNil(t, err)
+	require.Equal(t, "file glob src/../../valid-generator-structured/**/*.tmpl leads to file that is not inside base directory ../../../test/resources/valid-generator-simple - this is forbidden", err.Error())
+
+	// rejected before looking at the file system, so it does not matter whether anything is there
+	actual, err = cut.Glob(ctx, "../../does-not-exist/**", false)
+	require.Empty(t, actual)
+	require.NotNil(t, err)
+	require.Equal(t, "file glob ../../does-not-exist/** leads to file that is not inside base directory ../../../test/resources/valid-generator-simple - this is forbidden", err.Error())
+}
+
+func TestMatchGlob(t *testing.T) {
+	testcases := []struct {
+		pattern       string
+		path          string
+		includeHidden bool
+		expected      bool
+	}{
+		{"**", "a/b/c.txt", false, true},
+		{"**/*.md", "README.md", false, true},
+		{"**/*.md", "docs/sub/README.md", false, true},
+		{"**/*.md", "docs/README.txt", false, false},
+		{"docs/**/x.md", "docs/x.md", false, true},
+		{"**/*.md", ".github/README.md", false, false},
+		{"**/*.md", ".github/README.md", true, true},
+		{".github/**", ".github/workflows/go.yml", false, true},
+		{"*/*.md", "docs/README.md", false, true},
+		{"*/*.md", "docs/sub/README.md", false, false},
+	}
+	for _, tc := range testcases {
+		actual, err := MatchGlob(tc.pattern, tc.path, tc.includeHidden)
+		require.Nil(t, err)
+		require.Equal(t, tc.expected, actual, "%s vs %s", tc.pattern, tc.path)
+	}
+}
+
+func TestCatalogPrefixes(t *testing.T) {
+	actual, err := catalogPrefixes([]string{"/a/generators", "/b/generators", "/c/x/shared", "/d/x/shared", "/a/generators", "/other"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"a/generators", "b/generators", "c/x/shared", "d/x/shared", "a/generators", "other"}, actual)
+}
@@ -0,0 +1,114 @@
+package generatordir
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const recursiveWildcard = "**"
+
+// IsRecursiveGlob is true if the pattern contains a '**' path segment.
+func IsRecursiveGlob(pattern string) bool {
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == recursiveWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob reports whether the slash separated relative path matches the pattern.
+//
+// A '**' segment matches any number of path segments, all other segments are matched using path.Match.
+// Hidden files and directories are only matched by a wildcard if includeHidden is set.
+func MatchGlob(pattern string, relativePath string, includeHidden bool) (bool, error) {
+	return matchSegments(strings.Split(path.Clean(pattern), "/"), strings.Split(relativePath, "/"), includeHidden)
+}
+
+func (d *GeneratorDirectory) recursiveGlob(relativeGlob string, includeHidden bool) ([]string, error) {
+	segments := strings.Split(path.Clean(relativeGlob), "/")
+
+	// walk only below the part of the pattern that contains no wildcards
+	prefixLength := 0
+	for prefixLength < len(segments) && !hasMeta(segments[prefixLength]) {
+		prefixLength++
+	}
+	root := path.Join(d.baseDir, path.Join(segments[:prefixLength]...))
+	if rel, err := filepath.Rel(d.baseDir, root); err != nil || rel == ".." || strings.HasPrefix(strings.ReplaceAll(rel, `\`, `/`), "../") {
+		// do not even walk outside the base directory
+		return nil, fmt.Errorf("file glob %s leads to file that is not inside base directory %s - this is forbidden", relativeGlob, d.baseDir)
+	}
+	if _, err := os.Stat(root); err != nil {
+		// like filepath.Glob, a pattern that cannot match anything is not an error
+		return nil, nil
+	}
+
+	filenames := make([]string, 0)
+	err := filepath.Walk(root, func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, fn)
+		if err != nil {
+			// unreachable as far as I'm aware
+			return err
+		}
+
+		matches, err := matchSegments(segments[prefixLength:], strings.Split(filepath.ToSlash(rel), "/"), includeHidden)
+		if err != nil {
+			return err
+		}
+		if matches {
+			filenames = append(filenames, fn)
+		}
+		return nil
+	})
+	return filenames, err
+}
+
+func matchSegments(pattern []string, name []string, includeHidden bool) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == recursiveWildcard {
+			for consumed := 0; consumed <= len(name); consumed++ {
+				if consumed > 0 && !includeHidden && isHidden(name[consumed-1]) {
+					return false, nil
+				}
+				matches, err := matchSegments(pattern[1:], name[consumed:], includeHidden)
+				if err != nil || matches {
+					return matches, err
+				}
+			}
+			return false, nil
+		}
+
+		if len(name) == 0 {
+			return false, nil
+		}
+		if !includeHidden && isHidden(name[0]) && !strings.HasPrefix(pattern[0], ".") {
+			return false, nil
+		}
+		matches, err := path.Match(pattern[0], name[0])
+		if err != nil || !matches {
+			return false, err
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+func hasMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[\`)
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
@@ -0,0 +1,138 @@
+package targetdir
+
+import (
+	"github.com/StephanHCB/go-generator-lib/api"
+	yaml3 "gopkg.in/yaml.v3"
+	"strings"
+)
+
+// warningPrefix marks the comments written for api.RenderSpec.ParameterWarnings, so they can be told apart
+// from comments added by users.
+const warningPrefix = "TODO: "
+
+// annotateRenderSpec keeps all comments of the existing document, and adds the comments and warnings
+// requested in the render spec.
+//
+// Parameters that are already in the existing document keep their comments instead of getting new ones,
+// only warnings are replaced.
+func annotateRenderSpec(document *yaml3.Node, existing *yaml3.Node, renderSpec *api.RenderSpec) {
+	copyComments(existing, document)
+
+	root := document.Content[0]
+	existingRoot := documentRoot(existing)
+
+	annotateParameters(mappingValue(root, "parameters"), mappingValue(existingRoot, "parameters"),
+		renderSpec.ParameterComments, renderSpec.ParameterWarnings)
+	if generators := mappingValue(root, "generators"); generators != nil {
+		existingGenerators := mappingValue(existingRoot, "generators")
+		for n, invocation := range generators.Content {
+			if n >= len(renderSpec.Generators) {
+				continue
+			}
+			annotateParameters(mappingValue(invocation, "parameters"), mappingValue(sequenceItem(existingGenerators, n), "parameters"),
+				renderSpec.Generators[n].ParameterComments, renderSpec.Generators[n].ParameterWarnings)
+		}
+	}
+}
+
+func annotateParameters(parameters *yaml3.Node, existing *yaml3.Node, comments map[string]string, warnings map[string]string) {
+	if parameters == nil || parameters.Kind != yaml3.MappingNode {
+		return
+	}
+	for n := 0; n+1 < len(parameters.Content); n += 2 {
+		key, value := parameters.Content[n], parameters.Content[n+1]
+		for _, node := range []*yaml3.Node{key, value} {
+			if strings.HasPrefix(strings.TrimLeft(node.LineComment, "# "), warningPrefix) {
+				node.LineComment = ""
+			}
+		}
+
+		if mappingValue(existing, key.Value) == nil && comments[key.Value] != "" {
+			key.HeadComment = comments[key.Value]
+		}
+		if warnings[key.Value] != "" && value.Kind == yaml3.ScalarNode {
+			value.LineComment = warningPrefix + warnings[key.Value]
+		}
+	}
+}
+
+// copyComments copies the comments from the nodes of the existing document to the matching nodes of the
+// new document. Mappings are matched by key, sequences by position.
+func copyComments(existing *yaml3.Node, node *yaml3.Node) {
+	if existing == nil || node == nil || existing.Kind != node.Kind {
+		return
+	}
+	node.HeadComment = existing.HeadComment
+	node.LineComment = existing.LineComment
+	node.FootComment = existing.FootComment
+
+	switch node.Kind {
+	case yaml3.DocumentNode, yaml3.SequenceNode:
+		for n := 0; n < len(node.Content) && n < len(existing.Content); n++ {
+			copyComments(existing.Content[n], node.Content[n])
+		}
+	case yaml3.MappingNode:
+		for n := 0; n+1 < len(node.Content); n += 2 {
+			existingKey := mappingKey(existing, node.Content[n].Value)
+			if existingKey != nil {
+				copyComments(existingKey, node.Content[n])
+				copyComments(mappingValue(existing, node.Content[n].Value), node.Content[n+1])
+			}
+		}
+	}
+}
+
+// parameterOrder returns the requested order of the parameters. If none is requested, the parameters keep
+// the order they have in the existing mapping.
+func parameterOrder(order []string, existing *yaml3.Node) []string {
+	if len(order) > 0 || existing == nil || existing.Kind != yaml3.MappingNode {
+		return order
+	}
+	result := []string{}
+	for n := 0; n+1 < len(existing.Content); n += 2 {
+		result = append(result, existing.Content[n].Value)
+	}
+	return result
+}
+
+// documentRoot returns the top level node of a yaml document, or nil if the document is empty.
+func documentRoot(document *yaml3.Node) *yaml3.Node {
+	if document.Kind != yaml3.DocumentNode || len(document.Content) == 0 {
+		return nil
+	}
+	return document.Content[0]
+}
+
+// sequenceItem returns the item at index n of a sequence node, or nil.
+func sequenceItem(sequence *yaml3.Node, n int) *yaml3.Node {
+	if sequence == nil || sequence.Kind != yaml3.SequenceNode || n >= len(sequence.Content) {
+		return nil
+	}
+	return sequence.Content[n]
+}
+
+// mappingKey returns the key node for key in a mapping node, or nil.
+func mappingKey(mapping *yaml3.Node, key string) *yaml3.Node {
+	if mapping == nil || mapping.Kind != yaml3.MappingNode {
+		return nil
+	}
+	for n := 0; n+1 < len(mapping.Content); n += 2 {
+		if mapping.Content[n].Value == key {
+			return mapping.Content[n]
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(mapping *yaml3.Node, key string) *yaml3.Node {
+	if mapping == nil || mapping.Kind != yaml3.MappingNode {
+		return nil
+	}
+	for n := 0; n+1 < len(mapping.Content); n += 2 {
+		if mapping.Content[n].Value == key {
+			return mapping.Content[n+1]
+		}
+	}
+	return nil
+}
@@ -1,16 +1,20 @@
 package targetdir
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	aulogging "github.com/StephanHCB/go-autumn-logging"
 	"github.com/StephanHCB/go-generator-lib/api"
 	"gopkg.in/yaml.v2"
+	yaml3 "gopkg.in/yaml.v3"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"syscall"
 )
 
 type TargetDirectory struct{
@@ -53,7 +57,13 @@ func (d *TargetDirectory) ObtainRenderSpec(ctx context.Context, renderSpecFilena
 func (d *TargetDirectory) WriteRenderSpec(ctx context.Context, renderSpec *api.RenderSpec, renderSpecFilenameOrEmptyString string) (string, error) {
 	targetFile := d.RenderSpecFilenameOrDefaultForGenerator(ctx, renderSpecFilenameOrEmptyString, renderSpec.GeneratorName)
 
-	renderSpecYaml, err := d.renderRenderSpec(ctx, renderSpec)
+	var existingYaml []byte
+	if d.FileExists(ctx, targetFile) {
+		// keep the comments of the existing file
+		existingYaml, _ = d.ReadFile(ctx, targetFile)
+	}
+
+	renderSpecYaml, err := d.renderRenderSpec(ctx, renderSpec, existingYaml)
 	if err != nil {
 		// unreachable with current feature set as far as I'm aware
 		return targetFile, fmt.Errorf("error preparing render spec: %s", err.Error())
@@ -74,7 +84,8 @@ func (d *TargetDirectory) RenderSpecFilenameOrDefault(ctx context.Context, rende
 
 func (d *TargetDirectory) RenderSpecFilenameOrDefaultForGenerator(ctx context.Context, renderSpecFilename string, generatorName string) string {
 	if renderSpecFilename == "" {
-		result := "generated-" + generatorName + ".yaml"
+		// qualified generator names from a catalog contain slashes
+		result := "generated-" + strings.ReplaceAll(generatorName, "/", "-") + ".yaml"
 		aulogging.Logger.Ctx(ctx).Debug().Printf("using default renderSpec %s", result)
 		return result
 	}
@@ -95,15 +106,88 @@ func (d *TargetDirectory) ReadFile(ctx context.Context, relativePath string) ([]
 }
 
 func (d *TargetDirectory) WriteFile(ctx context.Context, relativePath string, contents []byte) error {
-	if err := d.CheckValid(ctx); err != nil {
-		return err
+	return d.writeFile(ctx, relativePath, contents, 0)
+}
+
+// WriteFileWithMode works like WriteFile, but also sets the file permissions, even if the file already existed.
+func (d *TargetDirectory) WriteFileWithMode(ctx context.Context, relativePath string, contents []byte, mode os.FileMode) error {
+	return d.writeFile(ctx, relativePath, contents, mode)
+}
+
+func (d *TargetDirectory) writeFile(ctx context.Context, relativePath string, contents []byte, mode os.FileMode) error {
+	_, err := d.WriteFileIfChanged(ctx, relativePath, contents, mode)
+	return err
+}
+
+// WriteFileIfChanged only writes the file if its contents or permissions differ from the existing file,
+// and reports what it did. Mode 0 works like WriteFile, any other mode like WriteFileWithMode.
+func (d *TargetDirectory) WriteFileIfChanged(ctx context.Context, relativePath string, contents []byte, mode os.FileMode) (api.FileAction, error) {
+	action, err := d.CompareFile(ctx, relativePath, contents, mode)
+	if err != nil || action == api.FileUnchanged {
+		return action, err
 	}
 
 	if err := d.createDirectoriesForFile(ctx, relativePath); err != nil {
+		return "", err
+	}
+
+	fullPath := path.Join(d.baseDir, relativePath)
+	mode, err = fileModeFor(fullPath, mode)
+	if err != nil {
+		return "", err
+	}
+	return action, writeFileAtomically(fullPath, contents, mode)
+}
+
+// CompareFile reports what writing the file would do, without writing anything.
+func (d *TargetDirectory) CompareFile(ctx context.Context, relativePath string, contents []byte, mode os.FileMode) (api.FileAction, error) {
+	if err := d.CheckValid(ctx); err != nil {
+		return "", err
+	}
+
+	fullPath := path.Join(d.baseDir, relativePath)
+	fileInfo, err := os.Stat(fullPath)
+	if err != nil {
+		return api.FileCreated, nil
+	}
+	if fileInfo.IsDir() {
+		// same error as writing the file directly would give
+		return "", &os.PathError{Op: "open", Path: fullPath, Err: syscall.EISDIR}
+	}
+	if mode != 0 && fileInfo.Mode().Perm() != mode {
+		return api.FileUpdated, nil
+	}
+	if fileInfo.Size() != int64(len(contents)) {
+		return api.FileUpdated, nil
+	}
+	existing, err := ioutil.ReadFile(fullPath)
+	if err != nil || !bytes.Equal(existing, contents) {
+		return api.FileUpdated, nil
+	}
+	return api.FileUnchanged, nil
+}
+
+// FileExists reports whether there is a file (not a directory) at relativePath.
+func (d *TargetDirectory) FileExists(ctx context.Context, relativePath string) bool {
+	if err := d.CheckValid(ctx); err != nil {
+		return false
+	}
+
+	fileInfo, err := os.Stat(path.Join(d.baseDir, relativePath))
+	return err == nil && !fileInfo.IsDir()
+}
+
+// DeleteFile removes the file at relativePath. It is not an error if there is no such file.
+func (d *TargetDirectory) DeleteFile(ctx context.Context, relativePath string) error {
+	if err := d.CheckValid(ctx); err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(path.Join(d.baseDir, relativePath), contents, 0644)
+	err := os.Remove(path.Join(d.baseDir, relativePath))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
 
 func (d *TargetDirectory) createDirectoriesForFile(ctx context.Context, relativePathForFile string) error {
@@ -126,6 +210,62 @@ func (d *TargetDirectory) createDirectoriesForFile(ctx context.Context, relative
 
 // --- helper methods ---
 
+// fileModeFor returns the mode to write a file with. If mode is 0, existing files keep their permissions,
+// and new files get 0644.
+func fileModeFor(fullPath string, mode os.FileMode) (os.FileMode, error) {
+	fileInfo, err := os.Stat(fullPath)
+	if err != nil {
+		if mode == 0 {
+			return 0644, nil
+		}
+		return mode, nil
+	}
+	if fileInfo.IsDir() {
+		// same error as writing the file directly would give
+		return 0, &os.PathError{Op: "open", Path: fullPath, Err: syscall.EISDIR}
+	}
+	if mode == 0 {
+		return fileInfo.Mode().Perm(), nil
+	}
+	return mode, nil
+}
+
+// writeFileAtomically writes the contents to a temporary file next to the target file, then renames it
+// to the target, so the target file is never left half-written.
+func writeFileAtomically(fullPath string, contents []byte, mode os.FileMode) error {
+	tmpPath, err := writeTemporaryFile(fullPath, contents, mode)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeTemporaryFile writes the contents to a new temporary file next to the target file, and returns its path.
+func writeTemporaryFile(fullPath string, contents []byte, mode os.FileMode) (string, error) {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(fullPath), "."+filepath.Base(fullPath)+".tmp*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmpFile.Name()
+
+	_, err = tmpFile.Write(contents)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, mode)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return "", err
+	}
+	return tmpPath, nil
+}
+
 func (d *TargetDirectory) parseRenderSpec(ctx context.Context, specYaml []byte) (*api.RenderSpec, error) {
 	spec := &api.RenderSpec{}
 	err := yaml.UnmarshalStrict(specYaml, spec)
@@ -135,7 +275,176 @@ func (d *TargetDirectory) parseRenderSpec(ctx context.Context, specYaml []byte)
 	return spec, nil
 }
 
-func (d *TargetDirectory) renderRenderSpec(ctx context.Context, renderSpec *api.RenderSpec) ([]byte, error) {
-	return yaml.Marshal(renderSpec)
+func (d *TargetDirectory) renderRenderSpec(ctx context.Context, renderSpec *api.RenderSpec, existingYaml []byte) ([]byte, error) {
+	existing := &yaml3.Node{}
+	if err := yaml3.Unmarshal(existingYaml, existing); err != nil {
+		// the file is overwritten anyway, there just are no comments to keep
+		aulogging.Logger.Ctx(ctx).Info().Printf("cannot keep comments from existing render spec: %s", err.Error())
+		existing = &yaml3.Node{}
+	}
+	existingRoot := documentRoot(existing)
+
+	specYaml, err := yaml.Marshal(renderSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	// yaml.Marshal sorts map keys, so read the result back in order and move the parameters around if requested
+	ordered := yaml.MapSlice{}
+	if err := yaml.Unmarshal(specYaml, &ordered); err != nil {
+		return nil, err
+	}
+	for n, item := range ordered {
+		switch item.Key {
+		case "parameters":
+			order := parameterOrder(renderSpec.ParameterOrder, mappingValue(existingRoot, "parameters"))
+			ordered[n].Value = orderParameters(item.Value, order)
+		case "generators":
+			existingGenerators := mappingValue(existingRoot, "generators")
+			generators, _ := item.Value.([]interface{})
+			for g := range generators {
+				invocation, ok := generators[g].(yaml.MapSlice)
+				if !ok || g >= len(renderSpec.Generators) {
+					continue
+				}
+				for k := range invocation {
+					if invocation[k].Key == "parameters" {
+						order := parameterOrder(renderSpec.Generators[g].ParameterOrder,
+							mappingValue(sequenceItem(existingGenerators, g), "parameters"))
+						invocation[k].Value = orderParameters(invocation[k].Value, order)
+					}
+				}
+			}
+		}
+	}
+	orderedYaml, err := yaml.Marshal(ordered)
+	if err != nil {
+		return nil, err
+	}
+
+	// read the result back as a yaml.v3 document, which can carry comments, and keeps the quoting of yaml.Marshal
+	document := &yaml3.Node{}
+	if err := yaml3.Unmarshal(orderedYaml, document); err != nil {
+		return nil, err
+	}
+	annotateRenderSpec(document, existing, renderSpec)
+
+	var buf bytes.Buffer
+	encoder := yaml3.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return unindentSequences(buf.Bytes()), nil
 }
 
+// orderParameters moves the parameters listed in order to the front, in that order. The others keep their order.
+func orderParameters(parameters interface{}, order []string) interface{} {
+	unordered, ok := parameters.(yaml.MapSlice)
+	if !ok || len(order) == 0 {
+		return parameters
+	}
+
+	result := yaml.MapSlice{}
+	used := make(map[string]bool)
+	for _, name := range order {
+		for _, item := range unordered {
+			if item.Key == name && !used[name] {
+				result = append(result, item)
+				used[name] = true
+			}
+		}
+	}
+	for _, item := range unordered {
+		if key, ok := item.Key.(string); !ok || !used[key] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// unindentSequences moves block sequences that are the value of a mapping key back to the indentation of the
+// key, where yaml.Marshal writes them. yaml.v3 always indents them by one more level, so without this, the
+// first rewrite of an existing render spec would change the indentation of all its lists.
+func unindentSequences(document []byte) []byte {
+	lines := strings.Split(string(document), "\n")
+	sequences := []int{} // the original indentation of the sequences that are moved, innermost last
+	scalarIndent := -1   // the lines of a block scalar are indented more than this
+	for n, line := range lines {
+		content := strings.TrimLeft(line, " ")
+		indent := len(line) - len(content)
+		if content == "" {
+			continue
+		}
+
+		if scalarIndent >= 0 && indent > scalarIndent {
+			lines[n] = unindent(line, 2*len(sequences))
+			continue
+		}
+		scalarIndent = -1
+
+		if strings.HasPrefix(content, "#") {
+			enclosing := 0
+			for _, sequenceIndent := range sequences {
+				if sequenceIndent <= indent {
+					enclosing++
+				}
+			}
+			lines[n] = unindent(line, 2*enclosing)
+			continue
+		}
+
+		for len(sequences) > 0 && sequences[len(sequences)-1] > indent {
+			sequences = sequences[:len(sequences)-1]
+		}
+		lines[n] = unindent(line, 2*len(sequences))
+
+		value := content
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = value[:comment]
+		}
+		value = strings.TrimRight(value, " ")
+		if blockScalarIndicator.MatchString(value) {
+			scalarIndent = indent
+			continue
+		}
+
+		keyIndent := indent
+		for strings.HasPrefix(value, "- ") {
+			item := strings.TrimLeft(value[2:], " ")
+			keyIndent += len(value) - len(item)
+			value = item
+		}
+		if strings.HasSuffix(value, ":") && nextContentIsSequenceItem(lines[n+1:], keyIndent+2) {
+			sequences = append(sequences, keyIndent+2)
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// a value that starts a literal or folded block scalar, e.g. 'key: |-'
+var blockScalarIndicator = regexp.MustCompile(`(^|: |- )[|>][0-9+-]*$`)
+
+// nextContentIsSequenceItem is true if the first line that is neither blank nor a comment is a sequence item
+// with the given indentation.
+func nextContentIsSequenceItem(lines []string, indent int) bool {
+	for _, line := range lines {
+		content := strings.TrimLeft(line, " ")
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		return len(line)-len(content) == indent && (content == "-" || strings.HasPrefix(content, "- "))
+	}
+	return false
+}
+
+// unindent removes up to count leading spaces from line.
+func unindent(line string, count int) string {
+	for n := 0; n < count && strings.HasPrefix(line, " "); n++ {
+		line = line[1:]
+	}
+	return line
+}
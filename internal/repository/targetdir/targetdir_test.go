@@ -75,3 +75,37 @@ func TestWriteFile_InTheWay2(t *testing.T) {
 	require.NotNil(t, actualErr, "unexpected nil error")
 	require.Equal(t, expected, actualErr.Error())
 }
+
+func TestUnindentSequences(t *testing.T) {
+	encoded := `generators:
+  # the first generator
+  - generator: main
+    parameters:
+      items:
+        - - nested
+        - name: one
+          tags:
+            - a
+      text: |-
+        key:
+          - not a list
+    # foot comment
+rendered_files: []
+`
+	expected := `generators:
+# the first generator
+- generator: main
+  parameters:
+    items:
+    - - nested
+    - name: one
+      tags:
+      - a
+    text: |-
+      key:
+        - not a list
+  # foot comment
+rendered_files: []
+`
+	require.Equal(t, expected, string(unindentSequences([]byte(encoded))))
+}
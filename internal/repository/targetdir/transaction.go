@@ -0,0 +1,168 @@
+package targetdir
+
+import (
+	"context"
+	"fmt"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// Transaction collects files to write to a target directory, and then writes either all or none of them.
+//
+// Staging files is safe from multiple goroutines.
+type Transaction struct {
+	dir    *TargetDirectory
+	mutex  sync.Mutex
+	staged []stagedFile
+}
+
+type stagedFile struct {
+	relativePath string
+	contents     []byte
+	mode         os.FileMode
+}
+
+// committedFile tracks the progress of a single file during Commit, so it can be rolled back.
+type committedFile struct {
+	fullPath   string
+	tmpPath    string
+	backupPath string
+	replaced   bool
+}
+
+// BeginTransaction starts collecting files to write. Nothing is written until Commit is called.
+func (d *TargetDirectory) BeginTransaction(_ context.Context) *Transaction {
+	return &Transaction{dir: d}
+}
+
+// WriteFile stages a file to be written on Commit, and reports what Commit will do with it. Files that are
+// unchanged are not staged at all. Mode 0 works like TargetDirectory.WriteFile, any other mode like
+// TargetDirectory.WriteFileWithMode.
+func (t *Transaction) WriteFile(ctx context.Context, relativePath string, contents []byte, mode os.FileMode) (api.FileAction, error) {
+	action, err := t.dir.CompareFile(ctx, relativePath, contents, mode)
+	if err != nil || action == api.FileUnchanged {
+		return action, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.staged = append(t.staged, stagedFile{
+		relativePath: relativePath,
+		contents:     contents,
+		mode:         mode,
+	})
+	return action, nil
+}
+
+// Commit writes all staged files. If any of them cannot be written, all changes are rolled back.
+//
+// All files are first written to temporary files next to their targets, then renamed into place one by one,
+// keeping a backup of any file they replace until all of them are in place.
+func (t *Transaction) Commit(ctx context.Context) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if err := t.dir.CheckValid(ctx); err != nil {
+		return err
+	}
+
+	createdDirs := []string{}
+	files := []*committedFile{}
+	rollback := func() {
+		for n := len(files) - 1; n >= 0; n-- {
+			files[n].rollback()
+		}
+		for n := len(createdDirs) - 1; n >= 0; n-- {
+			_ = os.RemoveAll(createdDirs[n])
+		}
+	}
+
+	for _, staged := range t.staged {
+		fullPath := path.Join(t.dir.baseDir, staged.relativePath)
+		missingDir := firstMissingDirectory(filepath.Dir(fullPath))
+		if err := t.dir.createDirectoriesForFile(ctx, staged.relativePath); err != nil {
+			rollback()
+			return err
+		}
+		if missingDir != "" {
+			createdDirs = append(createdDirs, missingDir)
+		}
+
+		mode, err := fileModeFor(fullPath, staged.mode)
+		if err != nil {
+			rollback()
+			return err
+		}
+		tmpPath, err := writeTemporaryFile(fullPath, staged.contents, mode)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to stage %s: %s", staged.relativePath, err)
+		}
+		files = append(files, &committedFile{fullPath: fullPath, tmpPath: tmpPath})
+	}
+
+	for _, file := range files {
+		if err := file.replace(); err != nil {
+			rollback()
+			return err
+		}
+	}
+
+	for _, file := range files {
+		if file.backupPath != "" {
+			_ = os.Remove(file.backupPath)
+		}
+	}
+	return nil
+}
+
+func (f *committedFile) replace() error {
+	if _, err := os.Stat(f.fullPath); err == nil {
+		backup, err := ioutil.TempFile(filepath.Dir(f.fullPath), "."+filepath.Base(f.fullPath)+".bak*")
+		if err != nil {
+			return err
+		}
+		_ = backup.Close()
+		if err := os.Rename(f.fullPath, backup.Name()); err != nil {
+			_ = os.Remove(backup.Name())
+			return err
+		}
+		f.backupPath = backup.Name()
+	}
+
+	if err := os.Rename(f.tmpPath, f.fullPath); err != nil {
+		return err
+	}
+	f.replaced = true
+	return nil
+}
+
+func (f *committedFile) rollback() {
+	if f.replaced {
+		_ = os.Remove(f.fullPath)
+	} else {
+		_ = os.Remove(f.tmpPath)
+	}
+	if f.backupPath != "" {
+		_ = os.Rename(f.backupPath, f.fullPath)
+	}
+}
+
+// firstMissingDirectory returns the topmost directory of dirPath that does not exist yet, or "" if it exists.
+func firstMissingDirectory(dirPath string) string {
+	missing := ""
+	for current := dirPath; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(current); err == nil {
+			return missing
+		}
+		missing = current
+		if filepath.Dir(current) == current {
+			return missing
+		}
+	}
+}
@@ -17,6 +17,14 @@ func FindGeneratorNames(ctx context.Context, sourceBaseDir string) ([]string, er
 	return Instance.FindGeneratorNames(ctx, sourceBaseDir)
 }
 
+func ListGenerators(ctx context.Context, sourceBaseDir string) ([]api.GeneratorInfo, error) {
+	return Instance.ListGenerators(ctx, sourceBaseDir)
+}
+
+func BuildCatalog(ctx context.Context, baseDirs []string) (*api.Catalog, error) {
+	return Instance.BuildCatalog(ctx, baseDirs)
+}
+
 func ObtainGeneratorSpec(ctx context.Context, sourceBaseDir string, generatorName string) (*api.GeneratorSpec, error) {
 	return Instance.ObtainGeneratorSpec(ctx, sourceBaseDir, generatorName)
 }
@@ -32,3 +40,11 @@ func WriteRenderSpecWithValues(ctx context.Context, request *api.Request, genera
 func Render(ctx context.Context, request *api.Request) *api.Response {
 	return Instance.Render(ctx, request)
 }
+
+func MigrateRenderSpec(ctx context.Context, request *api.Request) *api.Response {
+	return Instance.MigrateRenderSpec(ctx, request)
+}
+
+func Upgrade(ctx context.Context, request *api.Request) *api.Response {
+	return Instance.Upgrade(ctx, request)
+}
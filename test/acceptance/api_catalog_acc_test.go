@@ -0,0 +1,188 @@
+package acceptance
+
+import (
+	"context"
+	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/docs"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestBuildCatalog_ShouldFindGeneratorsInAllDirectories(t *testing.T) {
+	docs.Given("two generator directories with nested and hidden subdirectories")
+	basedirs := []string{"../resources/valid-catalog/team-a", "../resources/valid-catalog/team-b"}
+
+	docs.When("BuildCatalog is invoked")
+	actual, err := generatorlib.BuildCatalog(context.TODO(), basedirs)
+
+	docs.Then("all generators outside hidden directories are found, with qualified names")
+	require.Nil(t, err)
+	require.Equal(t, 3, len(actual.Entries))
+	require.Equal(t, api.CatalogEntry{
+		QualifiedName: "team-a/service",
+		SourceBaseDir: "../resources/valid-catalog/team-a",
+		Info:          api.GeneratorInfo{Name: "service", Title: "Team A Service", Compatible: true},
+	}, actual.Entries[0])
+	require.Equal(t, api.CatalogEntry{
+		QualifiedName: "team-b/backend/service",
+		SourceBaseDir: "../resources/valid-catalog/team-b/backend",
+		Info:          api.GeneratorInfo{Name: "service", Title: "Team B Backend Service", Compatible: true},
+	}, actual.Entries[1])
+	require.Equal(t, "team-b/worker", actual.Entries[2].QualifiedName)
+
+	docs.Then("generators can be found by qualified name, or by name if it is unique")
+	entry, err := actual.Find("team-b/backend/service")
+	require.Nil(t, err)
+	require.Equal(t, "Team B Backend Service", entry.Info.Title)
+	entry, err = actual.Find("worker")
+	require.Nil(t, err)
+	require.Equal(t, "team-b/worker", entry.QualifiedName)
+	_, err = actual.Find("service")
+	require.NotNil(t, err)
+	require.Equal(t, "generator name service is ambiguous, use one of the qualified names team-a/service, team-b/backend/service", err.Error())
+}
+
+func TestBuildCatalog_ShouldFailOnDuplicateQualifiedNames(t *testing.T) {
+	docs.Given("the same generator directory given twice")
+	basedirs := []string{"../resources/valid-catalog/team-a", "../resources/valid-catalog/team-a"}
+
+	docs.When("BuildCatalog is invoked")
+	actual, err := generatorlib.BuildCatalog(context.TODO(), basedirs)
+
+	docs.Then("an appropriate error is returned")
+	require.Equal(t, &api.Catalog{}, actual)
+	require.NotNil(t, err)
+	expectedErr := "qualified generator name team-a/service is not unique, found in ../resources/valid-catalog/team-a and ../resources/valid-catalog/team-a"
+	require.Equal(t, expectedErr, err.Error())
+}
+
+func TestBuildCatalog_ShouldDisambiguateDirectoriesWithTheSameName(t *testing.T) {
+	docs.Given("two generator directories with the same name")
+	basedirs := []string{"../resources/valid-catalog/team-a", "../resources/valid-catalog/archive/team-a"}
+
+	docs.When("BuildCatalog is invoked")
+	actual, err := generatorlib.BuildCatalog(context.TODO(), basedirs)
+
+	docs.Then("the qualified names include parent directories until they are unique")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(actual.Entries))
+	require.Equal(t, api.CatalogEntry{
+		QualifiedName: "archive/team-a/service",
+		SourceBaseDir: "../resources/valid-catalog/archive/team-a",
+		Info:          api.GeneratorInfo{Name: "service", Title: "Archived Team A Service", Compatible: true},
+	}, actual.Entries[0])
+	require.Equal(t, api.CatalogEntry{
+		QualifiedName: "valid-catalog/team-a/service",
+		SourceBaseDir: "../resources/valid-catalog/team-a",
+		Info:          api.GeneratorInfo{Name: "service", Title: "Team A Service", Compatible: true},
+	}, actual.Entries[1])
+}
+
+func TestBuildCatalog_ShouldListInvalidGenerators(t *testing.T) {
+	docs.Given("a directory with both valid and invalid generators below it")
+	basedirs := []string{"../resources"}
+
+	docs.When("BuildCatalog is invoked")
+	actual, err := generatorlib.BuildCatalog(context.TODO(), basedirs)
+
+	docs.Then("the catalog is built, and the invalid generators carry an appropriate error")
+	require.Nil(t, err)
+	entry, err := actual.Find("resources/valid-catalog/team-b/worker")
+	require.Nil(t, err)
+	require.Equal(t, "", entry.Info.Error)
+	entry, err = actual.Find("resources/invalid-generator-specs/unknownkey")
+	require.Nil(t, err)
+	require.False(t, entry.Info.Compatible)
+	require.Contains(t, entry.Info.Error, "field notvalid not found in type api.GeneratorSpec")
+}
+
+func TestRender_ShouldRenderGeneratorsFromCatalog(t *testing.T) {
+	docs.Given("a catalog of two generator directories and a valid target directory")
+	catalog, err := generatorlib.BuildCatalog(context.TODO(), []string{"../resources/valid-catalog/team-a", "../resources/valid-catalog/team-b"})
+	require.Nil(t, err)
+	targetdirpath := "../output/render-catalog-1"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file that references generators by qualified name and by unique name")
+	renderspec := `generators:
+  - generator: team-a/service
+    parameters:
+      serviceName: 'hello'
+  - generator: team-b/backend/service
+    parameters:
+      serviceName: 'world'
+    target: 'backend'
+  - generator: worker
+    parameters:
+      workerName: 'busy'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-main.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with the catalog")
+	request := &api.Request{
+		TargetBaseDir: targetdirpath,
+		Catalog:       catalog,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("each generator is read from its own directory")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 3, len(actualResponse.RenderedFiles))
+	readme, err := ioutil.ReadFile(targetdirpath + "/README.md")
+	require.Nil(t, err)
+	require.Equal(t, "team a service hello\n", toUnix(string(readme)))
+	readme, err = ioutil.ReadFile(targetdirpath + "/backend/README.md")
+	require.Nil(t, err)
+	require.Equal(t, "team b service world\n", toUnix(string(readme)))
+	worker, err := ioutil.ReadFile(targetdirpath + "/worker.txt")
+	require.Nil(t, err)
+	require.Equal(t, "worker busy\n", toUnix(string(worker)))
+
+	docs.When("Render is invoked for a render spec that uses an ambiguous name")
+	renderspec = `generator: service
+parameters:
+  serviceName: 'hello'
+`
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-main.yaml", []byte(renderspec)))
+	actualResponse = generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, "generator name service is ambiguous, use one of the qualified names team-a/service, team-b/backend/service", actualResponse.Errors[0].Error())
+}
+
+func TestWriteRenderSpecWithDefaults_ShouldUseQualifiedNameFromCatalog(t *testing.T) {
+	docs.Given("a catalog of two generator directories and a valid target directory")
+	catalog, err := generatorlib.BuildCatalog(context.TODO(), []string{"../resources/valid-catalog/team-a", "../resources/valid-catalog/team-b"})
+	require.Nil(t, err)
+	targetdirpath := "../output/write-render-spec-catalog-1"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.When("WriteRenderSpecWithDefaults is invoked with a qualified name")
+	request := &api.Request{
+		TargetBaseDir: targetdirpath,
+		Catalog:       catalog,
+	}
+	actualResponse := generatorlib.WriteRenderSpecWithDefaults(context.TODO(), request, "team-b/backend/service")
+
+	docs.Then("the render spec records the qualified name, and its file name is derived from it")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, "generated-team-b-backend-service.yaml", actualResponse.RenderedFiles[0].RelativeFilePath)
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	actual, err := dir.ReadFile(context.TODO(), "generated-team-b-backend-service.yaml")
+	require.Nil(t, err)
+	expectedContent := `generator: team-b/backend/service
+parameters:
+  # The name of the service to be rendered.
+  # required
+  serviceName: "" # TODO: required, please provide a value
+`
+	require.Equal(t, expectedContent, string(actual))
+}
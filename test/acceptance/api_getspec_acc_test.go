@@ -35,6 +35,7 @@ func TestObtainGeneratorSpec_ShouldReturnCorrectSpec(t *testing.T) {
 				ValidationPattern: "[a-zA-Z]+",
 			},
 		},
+		VariableOrder: []string{"serviceName"},
 	}
 	require.Nil(t, err)
 	require.Equal(t, expected, actual)
@@ -86,6 +87,7 @@ func TestObtainGeneratorSpec_ShouldReturnCorrectStructuredSpec(t *testing.T) {
 				},
 			},
 		},
+		VariableOrder: []string{"helloMessage", "structureList", "structureMap"},
 	}
 	require.Nil(t, err)
 	require.Equal(t, expected, actual)
@@ -155,3 +157,242 @@ func TestObtainGeneratorSpec_ShouldFailOnGeneratorDirWithTrailingSlash(t *testin
 	expectedErr := "invalid generator directory: baseDir ../resources/invalid-generator-specs/ must not contain trailing slash"
 	require.Equal(t, expectedErr, err.Error())
 }
+
+func TestObtainGeneratorSpec_ShouldMergeExtendedGenerator(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/valid-generator-simple"
+
+	docs.Given("a valid generator name of a generator that extends another one")
+	name := "extended"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("the merged spec is returned")
+	expected := &api.GeneratorSpec{
+		Extends: "override",
+		Templates: []api.TemplateSpec{
+			{
+				RelativeSourcePath: "override/config.txt.tmpl",
+				RelativeTargetPath: "config.txt",
+			},
+			{
+				RelativeSourcePath: "extended/README.md.tmpl",
+				RelativeTargetPath: "README.md",
+			},
+			{
+				RelativeSourcePath: "override/custom-config.txt.tmpl",
+				RelativeTargetPath: "config.txt",
+				Condition:          "{{ .customConfig }}",
+				AllowOverride:      true,
+			},
+			{
+				RelativeSourcePath: "extended/team.txt.tmpl",
+				RelativeTargetPath: "team.txt",
+			},
+		},
+		Variables: map[string]api.VariableSpec{
+			"serviceName": {
+				Description:       "The name of the service to be rendered.",
+				ValidationPattern: "^[a-z]+-service$",
+			},
+			"customConfig": {
+				Description:  "Set to true to replace the base configuration.",
+				DefaultValue: "false",
+			},
+			"teamName": {
+				Description:  "The name of the team that owns the service.",
+				DefaultValue: "platform",
+			},
+		},
+		VariableOrder: []string{"serviceName", "customConfig", "teamName"},
+	}
+	require.Nil(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func TestObtainGeneratorSpec_ShouldMergeBaseGeneratorFromOtherDirectory(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/valid-generator-simple"
+
+	docs.Given("a valid generator name of a generator that extends a generator in another directory")
+	name := "crossextended"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("the templates and includes of the base generator stay relative to its directory")
+	require.Nil(t, err)
+	require.Equal(t, []api.TemplateSpec{
+		{
+			RelativeSourcePath: "service/README.md.tmpl",
+			RelativeTargetPath: "README.md",
+			SourceDir:          "../valid-generator-composed",
+		},
+		{
+			RelativeSourcePath: "override/README.md.tmpl",
+			RelativeTargetPath: "TEAM.md",
+		},
+	}, actual.Templates)
+	require.Equal(t, 2, len(actual.Includes))
+	require.Equal(t, "../valid-generator-composed", actual.Includes[0].SourceDir)
+	require.Equal(t, "../valid-generator-composed/helm", actual.Includes[1].SourceDir)
+	require.Equal(t, []string{"serviceName", "withDatabase"}, actual.VariableOrder)
+}
+
+func TestObtainGeneratorSpec_ShouldFailOnExtendsCycle(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/invalid-generator-specs"
+
+	docs.Given("a valid generator name of a generator that indirectly extends itself")
+	name := "extendsa"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("an appropriate error is returned")
+	require.Equal(t, &api.GeneratorSpec{}, actual)
+	require.NotNil(t, err)
+	expectedErr := "error resolving base generator extendsb of extendsa: extends cycle detected: extendsa -> extendsb -> extendsa"
+	require.Equal(t, expectedErr, err.Error())
+}
+
+func TestObtainGeneratorSpec_ShouldFailOnMissingBaseGenerator(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/invalid-generator-specs"
+
+	docs.Given("a valid generator name of a generator that extends a generator that does not exist")
+	name := "extendsmissing"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("an appropriate error is returned")
+	require.Equal(t, &api.GeneratorSpec{}, actual)
+	require.NotNil(t, err)
+	expectedErrPart := "error resolving base generator notthere of extendsmissing: error reading generator spec file generator-notthere.yaml: "
+	require.Contains(t, err.Error(), expectedErrPart)
+}
+
+func TestObtainGeneratorSpec_ShouldFailOnBaseGeneratorWithMigrations(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/invalid-generator-specs"
+
+	docs.Given("a valid generator name of a generator that extends a generator with migrations")
+	name := "extendsmigrated"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("an appropriate error is returned")
+	require.Equal(t, &api.GeneratorSpec{}, actual)
+	require.NotNil(t, err)
+	expectedErr := "error resolving base generator migrated of extendsmigrated: base generator migrated has migrations, which cannot be inherited"
+	require.Equal(t, expectedErr, err.Error())
+}
+
+func TestObtainGeneratorSpec_ShouldFailOnInvalidVersion(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/invalid-generator-specs"
+
+	docs.Given("a valid generator name of a generator with a version that is not a semantic version")
+	name := "version"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("an appropriate error is returned")
+	require.Equal(t, &api.GeneratorSpec{}, actual)
+	require.NotNil(t, err)
+	expectedErr := "error parsing generator spec from file generator-version.yaml: version 'latest' is not a valid semantic version: Invalid Semantic Version"
+	require.Equal(t, expectedErr, err.Error())
+}
+
+func TestObtainGeneratorSpec_ShouldFailOnMigrationsWithoutVersion(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/invalid-generator-specs"
+
+	docs.Given("a valid generator name of a generator with migrations, but without a version")
+	name := "migrationsunversioned"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("an appropriate error is returned")
+	require.Equal(t, &api.GeneratorSpec{}, actual)
+	require.NotNil(t, err)
+	expectedErr := "error parsing generator spec from file generator-migrationsunversioned.yaml: migrations need a version of the generator"
+	require.Equal(t, expectedErr, err.Error())
+}
+
+func TestObtainGeneratorSpec_ShouldFailOnMigrationNewerThanGenerator(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/invalid-generator-specs"
+
+	docs.Given("a valid generator name of a generator with a migration to a version after its own")
+	name := "migrationsnewer"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("an appropriate error is returned")
+	require.Equal(t, &api.GeneratorSpec{}, actual)
+	require.NotNil(t, err)
+	expectedErr := "error parsing generator spec from file generator-migrationsnewer.yaml: version '2.0.0' of migration #1 is newer than the version '1.0.0' of the generator"
+	require.Equal(t, expectedErr, err.Error())
+}
+
+func TestObtainGeneratorSpec_ShouldFailOnGeneratorForNewerLibraryVersion(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/valid-generator-composed"
+
+	docs.Given("a valid generator name of a generator that needs a newer version of the library")
+	name := "future"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("an appropriate error is returned")
+	require.Equal(t, &api.GeneratorSpec{}, actual)
+	require.NotNil(t, err)
+	expectedErr := "cannot use generator future: it requires version 99.0.0 of go-generator-lib, but this is version " + api.LibraryVersion
+	require.Equal(t, expectedErr, err.Error())
+}
+
+func TestObtainGeneratorSpec_ShouldReturnPresentationMetadataInDeclarationOrder(t *testing.T) {
+	docs.Given("a valid generator source directory")
+	sourcedir := "../resources/valid-generator-simple"
+
+	docs.Given("the name of a generator whose variables have labels, groups, examples and help texts")
+	name := "presented"
+
+	docs.When("ObtainGeneratorSpec is invoked")
+	actual, err := generatorlib.ObtainGeneratorSpec(context.TODO(), sourcedir, name)
+
+	docs.Then("the presentation metadata is returned, and the variables are listed in the order they are declared")
+	require.Nil(t, err)
+	require.Equal(t, []string{"serviceName", "customConfig", "databaseName"}, actual.VariableOrder)
+	require.Equal(t, map[string]api.VariableSpec{
+		"serviceName": {
+			Description:       "The name of the service to be rendered.",
+			ValidationPattern: "^[a-z-]+$",
+			Label:             "Service Name",
+			Group:             "General",
+			Example:           "hello-service",
+		},
+		"customConfig": {
+			Description:  "Additional configuration for the service.",
+			DefaultValue: "",
+			Label:        "Custom Configuration",
+			Group:        "General",
+			Advanced:     true,
+			Help:         "Leave empty unless you know what you are doing. The value is included in the README as is.",
+		},
+		"databaseName": {
+			Description:  "The name of the database schema.",
+			DefaultValue: "app",
+			Label:        "Database Name",
+			Group:        "Database",
+		},
+	}, actual.Variables)
+}
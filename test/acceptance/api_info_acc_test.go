@@ -3,6 +3,7 @@ package acceptance
 import (
 	"context"
 	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
 	"github.com/StephanHCB/go-generator-lib/docs"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -16,7 +17,7 @@ func TestFindGeneratorNames_ShouldReturnCorrectList(t *testing.T) {
 	actual, err := generatorlib.FindGeneratorNames(context.TODO(), sourcedir)
 
 	docs.Then("the list of available generators is returned")
-	expected := []string{"docker", "emptydefaults", "files", "items", "justcopy", "main", "templatevars"}
+	expected := []string{"binary", "crossextended", "delimiters", "docker", "emptydefaults", "extended", "files", "format", "globs", "hooks", "items", "justcopy", "main", "migrated", "modes", "observed", "override", "presented", "skeleton", "suffixed", "templatevars", "versioned"}
 	require.Nil(t, err)
 	require.Equal(t, expected, actual)
 }
@@ -48,3 +49,59 @@ func TestFindGeneratorNames_ShouldComplainNotDirectory(t *testing.T) {
 	expectedErrorMsg := "invalid generator directory: baseDir ../resources/valid-generator-simple/generator-docker.yaml must be a directory"
 	require.Equal(t, expectedErrorMsg, err.Error())
 }
+
+func TestListGenerators_ShouldReturnMetadata(t *testing.T) {
+	docs.Given("a valid generator source directory with generators that have metadata")
+	sourcedir := "../resources/valid-generator-composed"
+
+	docs.When("ListGenerators is invoked")
+	actual, err := generatorlib.ListGenerators(context.TODO(), sourcedir)
+
+	docs.Then("all generators are returned with their metadata, sorted by name")
+	expected := []api.GeneratorInfo{
+		{
+			Name:              "future",
+			Title:             "Future",
+			Description:       "A generator that needs a newer version of the library.",
+			MinLibraryVersion: "99.0.0",
+			Compatible:        false,
+		},
+		{
+			Name:       "postgres",
+			Compatible: true,
+		},
+		{
+			Name:              "service",
+			Version:           "1.2.0",
+			Title:             "Service",
+			Description:       "A service with optional database support and a helm chart.",
+			Tags:              []string{"service", "helm"},
+			Owners:            []string{"platform-team"},
+			DocsURL:           "https://example.com/docs/service",
+			MinLibraryVersion: "1.6.0",
+			Compatible:        true,
+		},
+	}
+	require.Nil(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func TestListGenerators_ShouldReportInvalidGeneratorSpecs(t *testing.T) {
+	docs.Given("a generator source directory that contains invalid generator specs")
+	sourcedir := "../resources/invalid-generator-specs"
+
+	docs.When("ListGenerators is invoked")
+	actual, err := generatorlib.ListGenerators(context.TODO(), sourcedir)
+
+	docs.Then("all generators are listed, and those with invalid specs carry an appropriate error")
+	require.Nil(t, err)
+	require.Equal(t, 20, len(actual))
+	require.Equal(t, api.GeneratorInfo{Name: "cyclea", Compatible: true}, actual[0])
+	require.Equal(t, "duplicatekey", actual[2].Name)
+	require.False(t, actual[2].Compatible)
+	require.Contains(t, actual[2].Error, "error parsing generator spec from file generator-duplicatekey.yaml")
+	require.Equal(t, api.GeneratorInfo{
+		Name:  "version",
+		Error: "error parsing generator spec from file generator-version.yaml: version 'latest' is not a valid semantic version: Invalid Semantic Version",
+	}, actual[19])
+}
@@ -0,0 +1,198 @@
+package acceptance
+
+import (
+	"context"
+	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/docs"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestMigrateRenderSpec_ShouldApplyAllPendingMigrations(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/migrate-render-spec-1"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file for generator migrated that does not record a version")
+	renderspec := `generator: migrated
+parameters:
+  serviceName: 'Hello-Service'
+  serviceUrl: 'github.com/StephanHCB/hello-service'
+  dbName: 'orders'
+  legacyFlag: true
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-main.yaml", []byte(renderspec)))
+
+	docs.When("MigrateRenderSpec is invoked")
+	request := &api.Request{
+		SourceBaseDir: sourcedirpath,
+		TargetBaseDir: targetdirpath,
+	}
+	actualResponse := generatorlib.MigrateRenderSpec(context.TODO(), request)
+
+	docs.Then("the migrations up to the current version are applied in order of their versions and reported")
+	expectedResponse := &api.Response{
+		Success: true,
+		RenderedFiles: []api.FileResult{
+			{
+				Success:          true,
+				RelativeFilePath: "generated-main.yaml",
+				Action:           api.FileUpdated,
+			},
+		},
+		Changes: []string{
+			"renamed parameter 'serviceUrl' to 'modulePath' (migration to version 2.0.0)",
+			"moved parameter 'dbName' to 'database.name' (migration to version 2.0.0)",
+			"dropped parameter 'legacyFlag' (migration to version 2.0.0)",
+			"set parameter 'teamName' to 'platform' (migration to version 3.0.0)",
+			"transformed parameter 'serviceName' from 'Hello-Service' to 'hello-service' (migration to version 3.0.0)",
+			"recorded generator version 3.0.0",
+		},
+	}
+	require.Equal(t, expectedResponse, actualResponse)
+
+	docs.Then("the render spec file is updated in place")
+	expectedContent := `generator: migrated
+generator_version: 3.0.0
+parameters:
+  serviceName: hello-service
+  # The go module path of the service, used to be called serviceUrl.
+  # required
+  modulePath: github.com/StephanHCB/hello-service
+  # The database settings of the service.
+  # optional
+  database:
+    name: orders
+  # The name of the team that owns the service.
+  # required
+  teamName: platform
+`
+	actual, err := dir.ReadFile(context.TODO(), "generated-main.yaml")
+	require.Nil(t, err)
+	require.Equal(t, expectedContent, string(actual))
+
+	docs.When("MigrateRenderSpec is invoked again")
+	actualResponse = generatorlib.MigrateRenderSpec(context.TODO(), request)
+
+	docs.Then("nothing is changed")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, api.FileUnchanged, actualResponse.RenderedFiles[0].Action)
+	require.Equal(t, []string{}, actualResponse.Changes)
+}
+
+func TestMigrateRenderSpec_ShouldApplyMigrationsOnlyOnce(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/migrate-render-spec-4"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file for a generator with a migration that appends to a parameter")
+	renderspec := `generator: suffixed
+parameters:
+  serviceName: 'foo'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-main.yaml", []byte(renderspec)))
+
+	docs.When("MigrateRenderSpec is invoked twice")
+	request := &api.Request{
+		SourceBaseDir: sourcedirpath,
+		TargetBaseDir: targetdirpath,
+	}
+	firstResponse := generatorlib.MigrateRenderSpec(context.TODO(), request)
+	secondResponse := generatorlib.MigrateRenderSpec(context.TODO(), request)
+
+	docs.Then("the migration is applied by the first run only")
+	require.True(t, firstResponse.Success)
+	require.Equal(t, []string{
+		"transformed parameter 'serviceName' from 'foo' to 'foo-svc' (migration to version 1.0.0)",
+		"recorded generator version 1.0.0",
+	}, firstResponse.Changes)
+	require.True(t, secondResponse.Success)
+	require.Equal(t, api.FileUnchanged, secondResponse.RenderedFiles[0].Action)
+	require.Equal(t, []string{}, secondResponse.Changes)
+	expectedContent := `generator: suffixed
+generator_version: 1.0.0
+parameters:
+  serviceName: foo-svc
+`
+	actual, err := dir.ReadFile(context.TODO(), "generated-main.yaml")
+	require.Nil(t, err)
+	require.Equal(t, expectedContent, string(actual))
+}
+
+func TestMigrateRenderSpec_ShouldOnlyApplyMigrationsAfterRecordedVersion(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/migrate-render-spec-2"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file written for version 2.0.0 of generator migrated")
+	renderspec := `generator: migrated
+generator_version: 2.0.0
+parameters:
+  serviceName: 'hello-service'
+  modulePath: 'github.com/StephanHCB/hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-migrated.yaml", []byte(renderspec)))
+
+	docs.When("MigrateRenderSpec is invoked in a dry run")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-migrated.yaml",
+		DryRun:         true,
+	}
+	actualResponse := generatorlib.MigrateRenderSpec(context.TODO(), request)
+
+	docs.Then("only the later migrations are reported, and the file is not written")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, api.FileUpdated, actualResponse.RenderedFiles[0].Action)
+	require.Equal(t, []string{
+		"set parameter 'teamName' to 'platform' (migration to version 3.0.0)",
+		"recorded generator version 3.0.0",
+	}, actualResponse.Changes)
+	actual, err := dir.ReadFile(context.TODO(), "generated-migrated.yaml")
+	require.Nil(t, err)
+	require.Equal(t, renderspec, string(actual))
+}
+
+func TestMigrateRenderSpec_ShouldFailIfRenderSpecIsStillInvalid(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/migrate-render-spec-3"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file for generator migrated that lacks a required parameter")
+	renderspec := `generator: migrated
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-main.yaml", []byte(renderspec)))
+
+	docs.When("MigrateRenderSpec is invoked")
+	request := &api.Request{
+		SourceBaseDir: sourcedirpath,
+		TargetBaseDir: targetdirpath,
+	}
+	actualResponse := generatorlib.MigrateRenderSpec(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned and the file is not written")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.Errors))
+	require.Equal(t, "render spec is still invalid after migration: parameter 'modulePath' is required but missing", actualResponse.Errors[0].Error())
+	actual, err := dir.ReadFile(context.TODO(), "generated-main.yaml")
+	require.Nil(t, err)
+	require.Equal(t, renderspec, string(actual))
+}
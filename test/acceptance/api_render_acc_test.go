@@ -9,9 +9,12 @@ import (
 	"github.com/StephanHCB/go-generator-lib/docs"
 	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
 	"os"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRender_ShouldWriteExpectedFilesForDefault(t *testing.T) {
@@ -64,14 +67,18 @@ func main() {
 	expectedResponse := &api.Response{
 		Success: true,
 		RenderedFiles: []api.FileResult{
-			{
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename1,
-			},
-			{
+				Action:           api.FileCreated,
+				SourceTemplate:   "src/sub/sub.go.tmpl",
+			}),
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename2,
-			},
+				Action:           api.FileCreated,
+				SourceTemplate:   "src/main.go.tmpl",
+			}),
 		},
 	}
 	require.Equal(t, expectedResponse, actualResponse)
@@ -115,10 +122,12 @@ then look up something in a structure in a list: [sub 1 sub 2]
 	expectedResponse := &api.Response{
 		Success: true,
 		RenderedFiles: []api.FileResult{
-			{
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename1,
-			},
+				Action:           api.FileCreated,
+				SourceTemplate:   "main.txt.tmpl",
+			}),
 		},
 	}
 	require.Equal(t, expectedResponse, actualResponse)
@@ -168,6 +177,7 @@ func PrintMessage() {
 	require.True(t, actualResponse.RenderedFiles[0].Success)
 	require.Empty(t, actualResponse.RenderedFiles[0].Errors)
 	require.Equal(t, expectedFilename1, actualResponse.RenderedFiles[0].RelativeFilePath)
+	require.True(t, actualResponse.RenderedFiles[0].Copied)
 	require.False(t, actualResponse.RenderedFiles[1].Success)
 	require.Equal(t, expectedFilename2, actualResponse.RenderedFiles[1].RelativeFilePath)
 	// linux and windows produce different error messages
@@ -365,17 +375,33 @@ parameters: {}
 	expectedResponse := &api.Response{
 		Success: true,
 		RenderedFiles: []api.FileResult{
-			{
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename1,
-			},
-			{
+				Action:           api.FileCreated,
+				SourceTemplate:   "item.txt.tmpl",
+				Item:             map[interface{}]interface{}{"name": "Frank", "file": "first"},
+			}),
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename2,
-			},
-			{
+				Action:           api.FileCreated,
+				SourceTemplate:   "item.txt.tmpl",
+				Item:             map[interface{}]interface{}{"name": "John", "file": "second"},
+			}),
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename3,
+				Action:           api.FileCreated,
+				SourceTemplate:   "item.txt.tmpl",
+				Item:             map[interface{}]interface{}{"name": "Eve", "file": "third"},
+			}),
+			{
+				Success:          true,
+				RelativeFilePath: expectedFilename4,
+				Action:           api.FileSkipped,
+				SourceTemplate:   "item.txt.tmpl",
+				Item:             map[interface{}]interface{}{"name": "Tanja", "file": "fourth"},
 			},
 		},
 	}
@@ -603,10 +629,12 @@ func PrintMessage() {
 	expectedResponse := &api.Response{
 		Success: true,
 		RenderedFiles: []api.FileResult{
-			{
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename1,
-			},
+				Action:           api.FileCreated,
+				SourceTemplate:   "src/sub/orig.go.tmpl",
+			}),
 		},
 	}
 	require.Equal(t, expectedResponse, actualResponse)
@@ -673,10 +701,12 @@ missingDefault: ##
 	expectedResponse := &api.Response{
 		Success: true,
 		RenderedFiles: []api.FileResult{
-			{
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename1,
-			},
+				Action:           api.FileCreated,
+				SourceTemplate:   "src/strings.tmpl",
+			}),
 		},
 	}
 	require.Equal(t, expectedResponse, actualResponse)
@@ -801,18 +831,27 @@ func PrintMessage() {
 	expectedResponse := &api.Response{
 		Success: true,
 		RenderedFiles: []api.FileResult{
-			{
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename1,
-			},
-			{
+				Action:           api.FileCreated,
+				SourceTemplate:   "src/main.go.tmpl",
+				File:             "src/main.go.tmpl",
+			}),
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename2,
-			},
-			{
+				Action:           api.FileCreated,
+				SourceTemplate:   "src/sub/orig.go.tmpl",
+				File:             "src/sub/orig.go.tmpl",
+			}),
+			withDetails(t, dir, api.FileResult{
 				Success:          true,
 				RelativeFilePath: expectedFilename3,
-			},
+				Action:           api.FileCreated,
+				SourceTemplate:   "src/sub/sub.go.tmpl",
+				File:             "src/sub/sub.go.tmpl",
+			}),
 		},
 	}
 	require.Equal(t, expectedResponse, actualResponse)
@@ -826,3 +865,1120 @@ func PrintMessage() {
 	require.Nil(t, err)
 	require.Equal(t, expectedContent3, strings.Replace(string(actual3), "\r\n", "\n", -1))
 }
+
+func TestRender_ShouldWriteExpectedFilesForCustomDelimiters(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-20"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator delimiters, which sets custom delimiters globally and per template")
+	renderspec := `generator: delimiters
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-delimiters.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-delimiters.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value is as expected and double braces are left untouched")
+	expectedFilename1 := "workflow.yaml"
+	expectedContent1 := `name: hello-service
+run: echo ${{ secrets.SOME_TOKEN }}
+`
+	expectedFilename2 := "chart.yaml"
+	expectedContent2 := `name: HELLO-SERVICE
+image: {{ .Values.image }} [[ .serviceName ]]
+`
+	expectedResponse := &api.Response{
+		Success: true,
+		RenderedFiles: []api.FileResult{
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: expectedFilename1,
+				Action:           api.FileCreated,
+				SourceTemplate:   "delimiters/workflow.yaml.tmpl",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: expectedFilename2,
+				Action:           api.FileCreated,
+				SourceTemplate:   "delimiters/chart.yaml.tmpl",
+			}),
+		},
+	}
+	require.Equal(t, expectedResponse, actualResponse)
+	actual1, err := dir.ReadFile(context.TODO(), expectedFilename1)
+	require.Nil(t, err)
+	require.Equal(t, toUnix(expectedContent1), toUnix(string(actual1)))
+	actual2, err := dir.ReadFile(context.TODO(), expectedFilename2)
+	require.Nil(t, err)
+	require.Equal(t, toUnix(expectedContent2), toUnix(string(actual2)))
+}
+
+func TestRender_ShouldWriteExpectedFileModes(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-21"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator modes, which sets and inherits file modes")
+	renderspec := `generator: modes
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-modes.yaml", []byte(renderspec)))
+
+	docs.Given("the file to be rendered with the inherited mode already exists with different permissions")
+	require.Nil(t, dir.WriteFile(context.TODO(), "gradlew", []byte("old content")))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-modes.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value is as expected and the files have the correct permissions")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 3, len(actualResponse.RenderedFiles))
+	expectedModes := map[string]os.FileMode{
+		"run.sh":          0755,
+		"gradlew":         0755,
+		"run-readonly.sh": 0444,
+	}
+	for filename, expectedMode := range expectedModes {
+		fileInfo, err := os.Stat(targetdirpath + "/" + filename)
+		require.Nil(t, err)
+		if runtime.GOOS != "windows" {
+			require.Equal(t, expectedMode, fileInfo.Mode().Perm(), filename)
+		}
+	}
+	actual, err := dir.ReadFile(context.TODO(), "run.sh")
+	require.Nil(t, err)
+	require.Equal(t, "#!/bin/sh\necho \"starting hello-service\"\n", toUnix(string(actual)))
+}
+
+func TestRender_ShouldComplainIfInvalidFileMode(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/invalid-generator-specs"
+	targetdirpath := "../output/render-22"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator invalidmode")
+	renderspec := `generator: invalidmode
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-invalidmode.yaml", []byte(renderspec)))
+
+	docs.Given("the generator spec contains a template with an invalid mode")
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-invalidmode.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.RenderedFiles))
+	require.False(t, actualResponse.RenderedFiles[0].Success)
+	require.Equal(t, "error evaluating file mode for target 'item.txt': mode 'rwxr-xr-x' is not a valid octal file permission like '0755'", actualResponse.RenderedFiles[0].Errors[0].Error())
+}
+
+func TestRender_ShouldWriteExpectedFilesForDirectory(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-23"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator skeleton, which uses a directory template with templated paths")
+	renderspec := `generator: skeleton
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-skeleton.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-skeleton.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value is as expected and the correct files are written")
+	expectedFilename1 := "hello-service/.gitignore"
+	expectedContent1 := "build/\n"
+	expectedFilename2 := "hello-service/README.md"
+	expectedContent2 := "# {{ not rendered }}\n"
+	expectedFilename3 := "hello-service/src/com/example/service/Main.java"
+	expectedContent3 := `package com.example.service;
+
+public class Main {
+    // hello-service
+}
+`
+	expectedResponse := &api.Response{
+		Success: true,
+		RenderedFiles: []api.FileResult{
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: expectedFilename1,
+				Copied:           true,
+				Action:           api.FileCopied,
+				SourceTemplate:   "skeleton/.gitignore",
+				File:             "skeleton/.gitignore",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: expectedFilename2,
+				Copied:           true,
+				Action:           api.FileCopied,
+				SourceTemplate:   "skeleton/README.md",
+				File:             "skeleton/README.md",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: expectedFilename3,
+				Action:           api.FileCreated,
+				SourceTemplate:   "skeleton/src/{{.packagePath}}/Main.java.tmpl",
+				File:             "skeleton/src/{{.packagePath}}/Main.java.tmpl",
+			}),
+		},
+	}
+	require.Equal(t, expectedResponse, actualResponse)
+	actual1, err := dir.ReadFile(context.TODO(), expectedFilename1)
+	require.Nil(t, err)
+	require.Equal(t, expectedContent1, toUnix(string(actual1)))
+	actual2, err := dir.ReadFile(context.TODO(), expectedFilename2)
+	require.Nil(t, err)
+	require.Equal(t, expectedContent2, toUnix(string(actual2)))
+	actual3, err := dir.ReadFile(context.TODO(), expectedFilename3)
+	require.Nil(t, err)
+	require.Equal(t, expectedContent3, toUnix(string(actual3)))
+}
+
+func TestRender_ShouldNotEvaluateParameterValuesInDirectoryTargetPaths(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-40"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file for generator skeleton with a parameter value that looks like a template")
+	renderspec := `generator: skeleton
+parameters:
+  serviceName: 'hello-service'
+  packagePath: 'com/{{ .serviceName }}'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-skeleton.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-skeleton.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the parameter value is used in the target path as is")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 3, len(actualResponse.RenderedFiles))
+	require.Equal(t, "hello-service/src/com/{{ .serviceName }}/Main.java", actualResponse.RenderedFiles[2].RelativeFilePath)
+	require.True(t, dir.FileExists(context.TODO(), "hello-service/src/com/{{ .serviceName }}/Main.java"))
+}
+
+func TestRender_ShouldKeepHiddenFilesOfTopLevelDirectoryTemplate(t *testing.T) {
+	docs.Given("a generator source directory with a directory template for the whole directory, and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-rootdir"
+	targetdirpath := "../output/render-43"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator root")
+	renderspec := `generator: root
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-root.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-root.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("hidden files at the top of the directory keep their leading dot")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, "hello-service/.gitignore", actualResponse.RenderedFiles[0].RelativeFilePath)
+	actual, err := dir.ReadFile(context.TODO(), "hello-service/.gitignore")
+	require.Nil(t, err)
+	require.Equal(t, "/hello-service\n", toUnix(string(actual)))
+	require.True(t, dir.FileExists(context.TODO(), "hello-service/README.md"))
+}
+
+func TestRender_ShouldWriteExpectedFilesForRecursiveFileglobs(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-24"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator globs, which uses recursive and exclusion patterns in with_files")
+	renderspec := `generator: globs
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-globs.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-globs.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value is as expected and the correct files are written")
+	expectedFilenames := []string{
+		"visible/globs/sub/deeper/bottom.txt",
+		"visible/globs/sub/middle.txt",
+		"visible/globs/top.txt",
+		"all/globs/.hidden/secret.txt",
+		"all/globs/sub/middle.txt",
+		"all/globs/top.txt",
+	}
+	require.True(t, actualResponse.Success)
+	require.Equal(t, len(expectedFilenames), len(actualResponse.RenderedFiles))
+	for n, expectedFilename := range expectedFilenames {
+		require.True(t, actualResponse.RenderedFiles[n].Success)
+		require.Equal(t, expectedFilename, actualResponse.RenderedFiles[n].RelativeFilePath)
+	}
+	actual, err := dir.ReadFile(context.TODO(), "all/globs/.hidden/secret.txt")
+	require.Nil(t, err)
+	require.Equal(t, "globs/.hidden/secret.txt.tmpl for hello-service\n", toUnix(string(actual)))
+}
+
+func TestRender_ShouldCopyBinaryFiles(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-25"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator binary, whose with_files glob matches binary files")
+	renderspec := `generator: binary
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-binary.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-binary.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value is as expected and binary files are copied verbatim")
+	expectedResponse := &api.Response{
+		Success: true,
+		RenderedFiles: []api.FileResult{
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "binary/data.bin",
+				Copied:           true,
+				Action:           api.FileCopied,
+				SourceTemplate:   "binary/data.bin",
+				File:             "binary/data.bin",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "binary/forced.txt",
+				Action:           api.FileCreated,
+				SourceTemplate:   "binary/forced.txt",
+				File:             "binary/forced.txt",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "binary/latin1.txt",
+				Action:           api.FileCreated,
+				SourceTemplate:   "binary/latin1.txt",
+				File:             "binary/latin1.txt",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "binary/logo.png",
+				Copied:           true,
+				Action:           api.FileCopied,
+				SourceTemplate:   "binary/logo.png",
+				File:             "binary/logo.png",
+			}),
+		},
+	}
+	require.Equal(t, expectedResponse, actualResponse)
+	for _, filename := range []string{"binary/data.bin", "binary/logo.png"} {
+		expected, err := ioutil.ReadFile(sourcedirpath + "/" + filename)
+		require.Nil(t, err)
+		actual, err := dir.ReadFile(context.TODO(), filename)
+		require.Nil(t, err)
+		require.Equal(t, expected, actual)
+	}
+	actual, err := dir.ReadFile(context.TODO(), "binary/forced.txt")
+	require.Nil(t, err)
+	require.Equal(t, "text with a NUL \x00 for hello-service\n", string(actual))
+	actual, err = dir.ReadFile(context.TODO(), "binary/latin1.txt")
+	require.Nil(t, err)
+	require.Equal(t, "Gr\xfc\xdfe hello-service\n", string(actual))
+}
+
+func TestRender_ShouldWriteFormattedFiles(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-26"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator format, which formats its output")
+	renderspec := `generator: format
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-format.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-format.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value is as expected and the files are formatted")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 5, len(actualResponse.RenderedFiles))
+	expectedYaml := `# greetings to print
+greetings:
+  - hello
+  - hi
+settings:
+  verbose: true
+`
+	expectedContents := map[string]string{
+		"main.go.txt": `package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println("hello")
+	fmt.Println("hi")
+}
+`,
+		"data.json": `{
+  "greetings": [
+    "hello",
+    "hi"
+  ],
+  "count": 2
+}
+`,
+		"config.yaml": expectedYaml,
+		"config.txt":  expectedYaml,
+		"unformatted.yaml": `# greetings to print
+greetings:
+    -   hello
+    -   hi
+settings:
+      verbose: true
+`,
+	}
+	for filename, expectedContent := range expectedContents {
+		actual, err := dir.ReadFile(context.TODO(), filename)
+		require.Nil(t, err)
+		require.Equal(t, expectedContent, toUnix(string(actual)), filename)
+	}
+}
+
+func TestRender_ShouldComplainIfFormattingFails(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/invalid-generator-specs"
+	targetdirpath := "../output/render-27"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator format")
+	renderspec := `generator: format
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-format.yaml", []byte(renderspec)))
+
+	docs.Given("the generator renders syntactically invalid go code and uses an unknown formatter")
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-format.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("appropriate errors including the unformatted output are returned and no files are written")
+	unformatted := "package main\n\nfunc main() {\n\tfmt.Println(\"Hi\"\n}\n"
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 2, len(actualResponse.RenderedFiles))
+	require.False(t, actualResponse.RenderedFiles[0].Success)
+	// the exact syntax error message depends on the go version
+	require.Contains(t, actualResponse.RenderedFiles[0].Errors[0].Error(), "error formatting target 'main.go.txt': 4:")
+	require.Contains(t, toUnix(actualResponse.RenderedFiles[0].Errors[0].Error()), "\n--- unformatted output ---\n"+unformatted)
+	require.False(t, actualResponse.RenderedFiles[1].Success)
+	require.Equal(t, "error formatting target 'other.go.txt': unknown formatter 'prettier'\n--- unformatted output ---\n"+unformatted, toUnix(actualResponse.RenderedFiles[1].Errors[0].Error()))
+	_, err := dir.ReadFile(context.TODO(), "main.go.txt")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldApplyPostProcessors(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-28"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator delimiters")
+	renderspec := `generator: delimiters
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-delimiters.yaml", []byte(renderspec)))
+
+	docs.Given("a chain of two post processors")
+	addHeader := api.PostProcessorFunc(func(ctx context.Context, targetPath string, content []byte) ([]byte, error) {
+		return append([]byte("# license header for "+targetPath+"   \n"), content...), nil
+	})
+	stripTrailingWhitespace := api.PostProcessorFunc(func(ctx context.Context, targetPath string, content []byte) ([]byte, error) {
+		lines := strings.Split(string(content), "\n")
+		for n := range lines {
+			lines[n] = strings.TrimRight(lines[n], " \t")
+		}
+		return []byte(strings.Join(lines, "\n")), nil
+	})
+
+	docs.When("Render is invoked with the post processors")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-delimiters.yaml",
+		PostProcessors: []api.PostProcessor{addHeader, stripTrailingWhitespace},
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value is as expected and the post processors were applied in order")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 2, len(actualResponse.RenderedFiles))
+	actual, err := dir.ReadFile(context.TODO(), "workflow.yaml")
+	require.Nil(t, err)
+	require.Equal(t, "# license header for workflow.yaml\nname: hello-service\nrun: echo ${{ secrets.SOME_TOKEN }}\n", toUnix(string(actual)))
+}
+
+func TestRender_ShouldComplainIfPostProcessorFails(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-29"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator delimiters")
+	renderspec := `generator: delimiters
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-delimiters.yaml", []byte(renderspec)))
+
+	docs.Given("a post processor that fails for one of the files")
+	failForChart := api.PostProcessorFunc(func(ctx context.Context, targetPath string, content []byte) ([]byte, error) {
+		if targetPath == "chart.yaml" {
+			return nil, errors.New("charts are not allowed")
+		}
+		return content, nil
+	})
+
+	docs.When("Render is invoked with the post processor")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-delimiters.yaml",
+		PostProcessors: []api.PostProcessor{api.PostProcessorFunc(func(ctx context.Context, targetPath string, content []byte) ([]byte, error) { return content, nil }), failForChart},
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned and the file is not written")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 2, len(actualResponse.RenderedFiles))
+	require.True(t, actualResponse.RenderedFiles[0].Success)
+	require.False(t, actualResponse.RenderedFiles[1].Success)
+	require.Equal(t, "error in post processor #2 for target 'chart.yaml': charts are not allowed", actualResponse.RenderedFiles[1].Errors[0].Error())
+	_, err := dir.ReadFile(context.TODO(), "chart.yaml")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldWriteExpectedFilesInParallel(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-30"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator globs, which renders a number of files")
+	renderspec := `generator: globs
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-globs.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with a parallelism greater than one")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-globs.yaml",
+		Parallelism:    4,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value lists the files in the same order as for sequential rendering and the correct files are written")
+	expectedFilenames := []string{
+		"visible/globs/sub/deeper/bottom.txt",
+		"visible/globs/sub/middle.txt",
+		"visible/globs/top.txt",
+		"all/globs/.hidden/secret.txt",
+		"all/globs/sub/middle.txt",
+		"all/globs/top.txt",
+	}
+	require.True(t, actualResponse.Success)
+	require.Equal(t, len(expectedFilenames), len(actualResponse.RenderedFiles))
+	for n, expectedFilename := range expectedFilenames {
+		require.True(t, actualResponse.RenderedFiles[n].Success)
+		require.Equal(t, expectedFilename, actualResponse.RenderedFiles[n].RelativeFilePath)
+		actual, err := dir.ReadFile(context.TODO(), expectedFilename)
+		require.Nil(t, err)
+		expectedSource := strings.TrimPrefix(strings.TrimPrefix(expectedFilename, "visible/"), "all/") + ".tmpl"
+		require.Equal(t, expectedSource+" for hello-service\n", toUnix(string(actual)))
+	}
+}
+
+func TestRender_ShouldComplainIfDuplicateTargetsInParallel(t *testing.T) {
+	docs.Given("an invalid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/invalid-generator-specs"
+	targetdirpath := "../output/render-31"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator duplicatetargets, where two templates render the same target path")
+	renderspec := `generator: duplicatetargets
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-duplicatetargets.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with a parallelism greater than one")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-duplicatetargets.yaml",
+		Parallelism:    2,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned for the second file with the same target path, and the first one is written")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 3, len(actualResponse.RenderedFiles))
+	require.True(t, actualResponse.RenderedFiles[0].Success)
+	require.True(t, actualResponse.RenderedFiles[1].Success)
+	require.False(t, actualResponse.RenderedFiles[2].Success)
+	require.Equal(t, "target path first.txt is rendered by both template #1 (item.txt.tmpl) for item #1 and template #2 (item.txt.tmpl) for item #1 - set allow_override on the later template if this is intended", actualResponse.RenderedFiles[2].Errors[0].Error())
+	actual, err := dir.ReadFile(context.TODO(), "first.txt")
+	require.Nil(t, err)
+	require.Equal(t, "Hi Frank!\n", toUnix(string(actual)))
+}
+
+func TestRender_ShouldComplainIfDuplicateTargets(t *testing.T) {
+	docs.Given("an invalid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/invalid-generator-specs"
+	targetdirpath := "../output/render-32"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator duplicatetargets, where two templates render the same target path")
+	renderspec := `generator: duplicatetargets
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-duplicatetargets.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-duplicatetargets.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error naming both sources is returned for the second file with the same target path")
+	expectedResponse := &api.Response{
+		Success: false,
+		RenderedFiles: []api.FileResult{
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "first.txt",
+				Action:           api.FileCreated,
+				SourceTemplate:   "item.txt.tmpl",
+				Item:             map[interface{}]interface{}{"name": "Frank", "file": "first"},
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "second.txt",
+				Action:           api.FileCreated,
+				SourceTemplate:   "item.txt.tmpl",
+				Item:             map[interface{}]interface{}{"name": "John", "file": "second"},
+			}),
+			{
+				Success:          false,
+				RelativeFilePath: "first.txt",
+				Errors:           []error{errors.New("target path first.txt is rendered by both template #1 (item.txt.tmpl) for item #1 and template #2 (item.txt.tmpl) for item #1 - set allow_override on the later template if this is intended")},
+				SourceTemplate:   "item.txt.tmpl",
+				Item:             map[interface{}]interface{}{"name": "Eve"},
+			},
+		},
+		Errors: []error{errors.New("an error occurred during rendering, see individual files")},
+	}
+	require.Equal(t, expectedResponse, actualResponse)
+	actual, err := dir.ReadFile(context.TODO(), "first.txt")
+	require.Nil(t, err)
+	require.Equal(t, "Hi Frank!\n", toUnix(string(actual)))
+}
+
+func TestRender_ShouldAllowExplicitOverride(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-33"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator override, where a later template has allow_override set")
+	renderspec := `generator: override
+parameters:
+  serviceName: 'hello-service'
+  customConfig: 'true'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-override.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-override.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the later template replaces the earlier one")
+	expectedResponse := &api.Response{
+		Success: true,
+		RenderedFiles: []api.FileResult{
+			{
+				Success:          true,
+				RelativeFilePath: "config.txt",
+				Action:           api.FileSkipped,
+				SourceTemplate:   "override/config.txt.tmpl",
+			},
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "README.md",
+				Action:           api.FileCreated,
+				SourceTemplate:   "override/README.md.tmpl",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "config.txt",
+				Action:           api.FileCreated,
+				SourceTemplate:   "override/custom-config.txt.tmpl",
+			}),
+		},
+	}
+	require.Equal(t, expectedResponse, actualResponse)
+	actual, err := dir.ReadFile(context.TODO(), "config.txt")
+	require.Nil(t, err)
+	require.Equal(t, "custom config for hello-service\n", toUnix(string(actual)))
+}
+
+func TestRender_ShouldNotWriteFilesInDryRun(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-34"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator override, which does not use the override")
+	renderspec := `generator: override
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-override.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked as a dry run")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-override.yaml",
+		DryRun:         true,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the return value lists the files that would be written, but no files are written")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 3, len(actualResponse.RenderedFiles))
+	require.Equal(t, "config.txt", actualResponse.RenderedFiles[0].RelativeFilePath)
+	require.Equal(t, api.FileCreated, actualResponse.RenderedFiles[0].Action)
+	require.Equal(t, "README.md", actualResponse.RenderedFiles[1].RelativeFilePath)
+	require.Equal(t, api.FileCreated, actualResponse.RenderedFiles[1].Action)
+	require.Equal(t, "config.txt", actualResponse.RenderedFiles[2].RelativeFilePath)
+	require.Equal(t, api.FileSkipped, actualResponse.RenderedFiles[2].Action)
+	_, err := dir.ReadFile(context.TODO(), "config.txt")
+	require.NotNil(t, err)
+	_, err = dir.ReadFile(context.TODO(), "README.md")
+	require.NotNil(t, err)
+
+	docs.Then("the return value is the same as for actually rendering the files")
+	request.DryRun = false
+	require.Equal(t, generatorlib.Render(context.TODO(), request), actualResponse)
+}
+
+func TestRender_ShouldWriteAllFilesInTransactionalMode(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory with a previously rendered file")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-35"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "config.txt", []byte("old config\n")))
+
+	docs.Given("a valid render spec file for generator override")
+	renderspec := `generator: override
+parameters:
+  serviceName: 'hello-service'
+`
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-override.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked in transactional mode")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-override.yaml",
+		Transactional:  true,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("all files are written and no temporary files are left behind")
+	require.True(t, actualResponse.Success)
+	actual, err := dir.ReadFile(context.TODO(), "config.txt")
+	require.Nil(t, err)
+	require.Equal(t, "base config for hello-service\n", toUnix(string(actual)))
+	entries, err := ioutil.ReadDir(targetdirpath)
+	require.Nil(t, err)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Equal(t, []string{"README.md", "config.txt", "generated-override.yaml"}, names)
+}
+
+func TestRender_ShouldWriteNothingInTransactionalModeIfRenderingFails(t *testing.T) {
+	docs.Given("an invalid generator source directory and a valid target directory with a previously rendered file")
+	sourcedirpath := "../resources/invalid-generator-specs"
+	targetdirpath := "../output/render-36"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "first.txt", []byte("old contents\n")))
+
+	docs.Given("a valid render spec file for generator items, where one template has syntax errors")
+	renderspec := `generator: items
+parameters: {}
+`
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-items.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked in transactional mode")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-items.yaml",
+		Transactional:  true,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned and the target directory is unchanged")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, []error{
+		errors.New("an error occurred during rendering, see individual files"),
+		errors.New("rendering is transactional, so no files were written"),
+	}, actualResponse.Errors)
+	actual, err := dir.ReadFile(context.TODO(), "first.txt")
+	require.Nil(t, err)
+	require.Equal(t, "old contents\n", string(actual))
+	_, err = dir.ReadFile(context.TODO(), "second.txt")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldRollBackInTransactionalModeIfWritingFails(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory with a previously rendered file")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-37"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "hello-service/README.md", []byte("old readme\n")))
+
+	docs.Given("a file is in the way of one of the target directories")
+	require.Nil(t, dir.WriteFile(context.TODO(), "hello-service/src", []byte("in the way\n")))
+
+	docs.Given("a valid render spec file for generator skeleton")
+	renderspec := `generator: skeleton
+parameters:
+  serviceName: 'hello-service'
+`
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-skeleton.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked in transactional mode")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-skeleton.yaml",
+		Transactional:  true,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned and the target directory is unchanged")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.Errors))
+	require.Contains(t, actualResponse.Errors[0].Error(), "failed to write rendered files, no files were changed: cannot create path up to ../output/render-37/hello-service/src/com/example/service")
+	actual, err := dir.ReadFile(context.TODO(), "hello-service/README.md")
+	require.Nil(t, err)
+	require.Equal(t, "old readme\n", string(actual))
+	entries, err := ioutil.ReadDir(targetdirpath + "/hello-service")
+	require.Nil(t, err)
+	require.Equal(t, 2, len(entries))
+}
+
+func TestRender_ShouldReportCreatedUpdatedAndUnchangedFiles(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-38"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator override")
+	renderspec := `generator: override
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-override.yaml", []byte(renderspec)))
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-override.yaml",
+	}
+
+	docs.When("Render is invoked for the first time")
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("all files are reported as created")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, api.FileCreated, actualResponse.RenderedFiles[0].Action)
+	require.Equal(t, api.FileCreated, actualResponse.RenderedFiles[1].Action)
+
+	docs.When("Render is invoked again with the same parameters")
+	readmeInfoBefore, err := os.Stat(targetdirpath + "/README.md")
+	require.Nil(t, err)
+	require.Nil(t, os.Chtimes(targetdirpath+"/README.md", readmeInfoBefore.ModTime().Add(-time.Hour), readmeInfoBefore.ModTime().Add(-time.Hour)))
+	actualResponse = generatorlib.Render(context.TODO(), request)
+
+	docs.Then("all files are reported as unchanged and are not written")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, api.FileUnchanged, actualResponse.RenderedFiles[0].Action)
+	require.Equal(t, api.FileUnchanged, actualResponse.RenderedFiles[1].Action)
+	readmeInfoAfter, err := os.Stat(targetdirpath + "/README.md")
+	require.Nil(t, err)
+	require.Equal(t, readmeInfoBefore.ModTime().Add(-time.Hour), readmeInfoAfter.ModTime())
+
+	docs.When("Render is invoked again with a parameter that changes one of the files")
+	renderspec = `generator: override
+parameters:
+  serviceName: 'hello-service'
+  customConfig: 'true'
+`
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-override.yaml", []byte(renderspec)))
+	actualResponse = generatorlib.Render(context.TODO(), request)
+
+	docs.Then("only that file is reported as updated")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, "config.txt", actualResponse.RenderedFiles[0].RelativeFilePath)
+	require.Equal(t, api.FileSkipped, actualResponse.RenderedFiles[0].Action)
+	require.Equal(t, "README.md", actualResponse.RenderedFiles[1].RelativeFilePath)
+	require.Equal(t, api.FileUnchanged, actualResponse.RenderedFiles[1].Action)
+	require.Equal(t, "config.txt", actualResponse.RenderedFiles[2].RelativeFilePath)
+	require.Equal(t, api.FileUpdated, actualResponse.RenderedFiles[2].Action)
+}
+
+func TestRender_ShouldRenderExtendedGenerator(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-39"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator extended, which extends generator override")
+	renderspec := `generator: extended
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-extended.yaml", []byte(renderspec)))
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-extended.yaml",
+	}
+
+	docs.When("Render is invoked")
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the templates of both generators are rendered, with the replaced template taken from generator extended")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 4, len(actualResponse.RenderedFiles))
+	require.Equal(t, "README.md", actualResponse.RenderedFiles[1].RelativeFilePath)
+	require.Equal(t, "extended/README.md.tmpl", actualResponse.RenderedFiles[1].SourceTemplate)
+	require.Equal(t, api.FileSkipped, actualResponse.RenderedFiles[2].Action)
+	require.Equal(t, "team.txt", actualResponse.RenderedFiles[3].RelativeFilePath)
+
+	docs.Then("the variables of both generators are available")
+	readme, err := ioutil.ReadFile(targetdirpath + "/README.md")
+	require.Nil(t, err)
+	require.Equal(t, "readme for hello-service, owned by team platform\n", toUnix(string(readme)))
+	config, err := ioutil.ReadFile(targetdirpath + "/config.txt")
+	require.Nil(t, err)
+	require.Equal(t, "base config for hello-service\n", toUnix(string(config)))
+
+	docs.When("Render is invoked with a service name that the base generator accepts, but the extended one does not")
+	renderspec = `generator: extended
+parameters:
+  serviceName: 'hello'
+`
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-extended.yaml", []byte(renderspec)))
+	actualResponse = generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the overridden validation pattern applies")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.Errors))
+	require.Contains(t, actualResponse.Errors[0].Error(), "serviceName")
+}
+
+func TestRender_ShouldRenderGeneratorExtendingGeneratorFromOtherDirectory(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-41"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator crossextended, which extends a generator in another directory")
+	renderspec := `generator: crossextended
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-crossextended.yaml", []byte(renderspec)))
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-crossextended.yaml",
+	}
+
+	docs.When("Render is invoked")
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the templates and included generators of the base generator are read from its directory")
+	require.True(t, actualResponse.Success)
+	for _, filename := range []string{"README.md", "TEAM.md", "db/schema.sql"} {
+		require.True(t, dir.FileExists(context.TODO(), filename), filename)
+	}
+	team, err := ioutil.ReadFile(targetdirpath + "/TEAM.md")
+	require.Nil(t, err)
+	require.Equal(t, "readme for hello-service\n", toUnix(string(team)))
+
+	docs.Then("inherited templates are reported with the path to the base generator's directory")
+	require.Equal(t, "README.md", actualResponse.RenderedFiles[0].RelativeFilePath)
+	require.Equal(t, "../valid-generator-composed/service/README.md.tmpl", actualResponse.RenderedFiles[0].SourceTemplate)
+	require.Equal(t, "TEAM.md", actualResponse.RenderedFiles[1].RelativeFilePath)
+	require.Equal(t, "override/README.md.tmpl", actualResponse.RenderedFiles[1].SourceTemplate)
+}
+
+func TestRender_ShouldNotChangeRenderSpecFile(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-42"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator items, with a comment, quotes and 4 spaces indentation")
+	renderspec := `generator: items
+parameters:
+    # the greeting
+    message: 'Hello'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-items.yaml", []byte(renderspec)))
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-items.yaml",
+	}
+
+	docs.When("Render is invoked")
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the files are rendered, and the render spec file is left byte for byte unchanged")
+	require.True(t, actualResponse.Success)
+	actual, err := dir.ReadFile(context.TODO(), "generated-items.yaml")
+	require.Nil(t, err)
+	require.Equal(t, renderspec, string(actual))
+}
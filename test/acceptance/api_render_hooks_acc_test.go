@@ -0,0 +1,154 @@
+package acceptance
+
+import (
+	"context"
+	"errors"
+	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/docs"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func _testRender_hooks(t *testing.T, targetdirpath string, renderspec string, executeHooks bool) (*api.Response, *targetdir.TargetDirectory) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests need a posix shell")
+	}
+
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator hooks, which declares pre and post render hooks")
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-hooks.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-hooks.yaml",
+		ExecuteHooks:   executeHooks,
+	}
+	return generatorlib.Render(context.TODO(), request), dir
+}
+
+func TestRender_ShouldRunHooksIfEnabled(t *testing.T) {
+	renderspec := `generator: hooks
+parameters: {}
+`
+	actualResponse, dir := _testRender_hooks(t, "../output/render-hooks-1", renderspec, true)
+
+	docs.Then("the files are rendered and the hooks are run in order, skipping hooks whose condition is false")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.RenderedFiles))
+	expectedHookResults := []api.HookResult{
+		{
+			Success:    true,
+			Command:    []string{"sh", "-c", `echo "hello world" > pre.txt`},
+			WorkingDir: ".",
+		},
+		{
+			Success:    true,
+			Command:    []string{"sh", "-c", `ls; echo "hello world" > post.txt`},
+			WorkingDir: "sub",
+			Output:     "sub.go.txt\n",
+		},
+		{
+			Success:    true,
+			Command:    []string{"sh", "-c", "exit 0"},
+			WorkingDir: ".",
+		},
+	}
+	require.Equal(t, expectedHookResults, actualResponse.HookResults)
+	actual, err := dir.ReadFile(context.TODO(), "pre.txt")
+	require.Nil(t, err)
+	require.Equal(t, "hello world\n", string(actual))
+	actual, err = dir.ReadFile(context.TODO(), "sub/post.txt")
+	require.Nil(t, err)
+	require.Equal(t, "hello world\n", string(actual))
+	_, err = dir.ReadFile(context.TODO(), "skipped.txt")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldNotRunHooksUnlessEnabled(t *testing.T) {
+	renderspec := `generator: hooks
+parameters: {}
+`
+	actualResponse, dir := _testRender_hooks(t, "../output/render-hooks-2", renderspec, false)
+
+	docs.Then("the files are rendered but no hooks are run")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.RenderedFiles))
+	require.Empty(t, actualResponse.HookResults)
+	_, err := dir.ReadFile(context.TODO(), "pre.txt")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldReportFailingPostRenderHook(t *testing.T) {
+	renderspec := `generator: hooks
+parameters:
+  exitCode: '7'
+`
+	actualResponse, _ := _testRender_hooks(t, "../output/render-hooks-3", renderspec, true)
+
+	docs.Then("the files are rendered, but the failed hook is reported with its exit code")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.RenderedFiles))
+	require.True(t, actualResponse.RenderedFiles[0].Success)
+	require.Equal(t, []error{errors.New("a post render hook failed, see hook results")}, actualResponse.Errors)
+	require.Equal(t, 3, len(actualResponse.HookResults))
+	require.False(t, actualResponse.HookResults[2].Success)
+	require.Equal(t, 7, actualResponse.HookResults[2].ExitCode)
+	require.Equal(t, "post render hook #2 (sh -c exit 7) failed: exit status 7", actualResponse.HookResults[2].Errors[0].Error())
+}
+
+func TestRender_ShouldNotRenderIfPreRenderHookFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook tests need a posix shell")
+	}
+
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/invalid-generator-specs"
+	targetdirpath := "../output/render-hooks-4"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator hooks, which has a failing pre render hook")
+	renderspec := `generator: hooks
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-hooks.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with hooks enabled")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-hooks.yaml",
+		ExecuteHooks:   true,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("nothing is rendered and the failed hook is reported")
+	require.False(t, actualResponse.Success)
+	require.Empty(t, actualResponse.RenderedFiles)
+	require.Equal(t, []error{errors.New("a pre render hook failed, nothing was rendered, see hook results")}, actualResponse.Errors)
+	expectedHookResults := []api.HookResult{
+		{
+			Success:    false,
+			Command:    []string{"sh", "-c", "echo failing; exit 3"},
+			WorkingDir: ".",
+			ExitCode:   3,
+			Output:     "failing\n",
+			Errors:     []error{errors.New("pre render hook #1 (sh -c echo failing; exit 3) failed: exit status 3")},
+		},
+	}
+	require.Equal(t, expectedHookResults, actualResponse.HookResults)
+	_, err := dir.ReadFile(context.TODO(), "item.txt")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,162 @@
+package acceptance
+
+import (
+	"context"
+	"errors"
+	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/docs"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestRender_ShouldRenderIncludedGenerators(t *testing.T) {
+	docs.Given("a valid generator source directory with a generator that includes other generators, and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-composed"
+	targetdirpath := "../output/render-includes-1"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator service")
+	renderspec := `generator: service
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-service.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-service.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the files of all generators are rendered in one response, with the mapped parameters and in their target directories")
+	expectedResponse := &api.Response{
+		Success: true,
+		RenderedFiles: []api.FileResult{
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "README.md",
+				Action:           api.FileCreated,
+				SourceTemplate:   "service/README.md.tmpl",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "db/schema.sql",
+				Action:           api.FileCreated,
+				SourceTemplate:   "postgres/schema.sql.tmpl",
+			}),
+			withDetails(t, dir, api.FileResult{
+				Success:          true,
+				RelativeFilePath: "helm/hello-service/Chart.yaml",
+				Action:           api.FileCreated,
+				SourceTemplate:   "chart/Chart.yaml.tmpl",
+			}),
+		},
+	}
+	require.Equal(t, expectedResponse, actualResponse)
+	actual, err := dir.ReadFile(context.TODO(), "db/schema.sql")
+	require.Nil(t, err)
+	require.Equal(t, "CREATE DATABASE hello_service OWNER app;\n", toUnix(string(actual)))
+	actual, err = dir.ReadFile(context.TODO(), "helm/hello-service/Chart.yaml")
+	require.Nil(t, err)
+	require.Equal(t, "apiVersion: v2\nname: hello-service\n", toUnix(string(actual)))
+}
+
+func TestRender_ShouldSkipIncludedGeneratorIfConditionFalse(t *testing.T) {
+	docs.Given("a valid generator source directory with a generator that includes other generators, and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-composed"
+	targetdirpath := "../output/render-includes-2"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator service that turns off the database support")
+	renderspec := `generator: service
+parameters:
+  serviceName: 'hello-service'
+  withDatabase: 'false'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-service.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-service.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the included generator whose condition is false is not rendered")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 2, len(actualResponse.RenderedFiles))
+	require.Equal(t, "README.md", actualResponse.RenderedFiles[0].RelativeFilePath)
+	require.Equal(t, "helm/hello-service/Chart.yaml", actualResponse.RenderedFiles[1].RelativeFilePath)
+	_, err := dir.ReadFile(context.TODO(), "db/schema.sql")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldComplainIfIncludeCycle(t *testing.T) {
+	docs.Given("an invalid generator source directory with generators that include each other, and a valid target directory")
+	sourcedirpath := "../resources/invalid-generator-specs"
+	targetdirpath := "../output/render-includes-3"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator cyclea")
+	renderspec := `generator: cyclea
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-cyclea.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-cyclea.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned and nothing is rendered")
+	require.False(t, actualResponse.Success)
+	require.Empty(t, actualResponse.RenderedFiles)
+	require.Equal(t, 1, len(actualResponse.Errors))
+	require.Equal(t, "include cycle detected: ../resources/invalid-generator-specs/cyclea -> ../resources/invalid-generator-specs/cycleb -> ../resources/invalid-generator-specs/cyclea", actualResponse.Errors[0].Error())
+	_, err := dir.ReadFile(context.TODO(), "a.txt")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldComplainIfIncludedParametersInvalid(t *testing.T) {
+	docs.Given("a valid generator source directory with a generator that includes other generators, and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-composed"
+	targetdirpath := "../output/render-includes-4"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator service, whose service name maps to an invalid database name")
+	renderspec := `generator: service
+parameters:
+  serviceName: '-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-service.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-service.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned and nothing is rendered")
+	require.False(t, actualResponse.Success)
+	require.Empty(t, actualResponse.RenderedFiles)
+	require.Equal(t, []error{errors.New("invalid parameters for included generator postgres: value for parameter 'databaseName' does not match pattern ^[a-z][a-z_]*$")}, actualResponse.Errors)
+}
@@ -0,0 +1,162 @@
+package acceptance
+
+import (
+	"context"
+	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/docs"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRender_ShouldRenderMultipleGenerators(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-multi-1"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file that lists two generators, one of them rendered into a subdirectory")
+	renderspec := `generators:
+  - generator: override
+    parameters:
+      serviceName: 'hello-service'
+  - generator: docker
+    parameters:
+      serviceName: 'hello'
+    target: 'docker'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-all.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-all.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the files of both generators are rendered in order, each with its own parameters")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 4, len(actualResponse.RenderedFiles))
+	require.Equal(t, "config.txt", actualResponse.RenderedFiles[0].RelativeFilePath)
+	require.Equal(t, "README.md", actualResponse.RenderedFiles[1].RelativeFilePath)
+	require.Equal(t, "config.txt", actualResponse.RenderedFiles[2].RelativeFilePath)
+	require.Equal(t, api.FileSkipped, actualResponse.RenderedFiles[2].Action)
+	require.Equal(t, "docker/Dockerfile", actualResponse.RenderedFiles[3].RelativeFilePath)
+	require.Equal(t, api.FileCreated, actualResponse.RenderedFiles[3].Action)
+
+	readme, err := ioutil.ReadFile(targetdirpath + "/README.md")
+	require.Nil(t, err)
+	require.Equal(t, "readme for hello-service\n", toUnix(string(readme)))
+	dockerfile, err := ioutil.ReadFile(targetdirpath + "/docker/Dockerfile")
+	require.Nil(t, err)
+	require.Contains(t, toUnix(string(dockerfile)), "CMD [\"/hello\"]\n")
+}
+
+func TestRender_ShouldFailOnConflictingTargetsOfMultipleGenerators(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-multi-2"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file that lists two generators writing the same file")
+	renderspec := `generators:
+  - generator: docker
+    parameters:
+      serviceName: 'hello'
+  - generator: docker
+    parameters:
+      serviceName: 'world'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-all.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-all.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the later file fails with an error that names both generators")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 2, len(actualResponse.RenderedFiles))
+	require.True(t, actualResponse.RenderedFiles[0].Success)
+	require.False(t, actualResponse.RenderedFiles[1].Success)
+	expectedErr := "target path Dockerfile is rendered by both template #1 (Dockerfile.tmpl) of generator #1 (docker) and template #1 (Dockerfile.tmpl) of generator #2 (docker) - set allow_override on the later template if this is intended"
+	require.Equal(t, expectedErr, actualResponse.RenderedFiles[1].Errors[0].Error())
+}
+
+func TestRender_ShouldFailOnInvalidParametersOfOneOfMultipleGenerators(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-multi-3"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file that lists two generators, the second one with a missing parameter")
+	renderspec := `generators:
+  - generator: override
+    parameters:
+      serviceName: 'hello-service'
+  - generator: docker
+    parameters: {}
+    target: 'docker'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-all.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-all.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("nothing is rendered and an appropriate error is returned")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 0, len(actualResponse.RenderedFiles))
+	require.Equal(t, 1, len(actualResponse.Errors))
+	require.Equal(t, "error preparing generator #2 (docker): parameter 'serviceName' is required but missing", actualResponse.Errors[0].Error())
+	_, err := os.Stat(targetdirpath + "/README.md")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestRender_ShouldFailOnRenderSpecWithSingleAndMultipleGenerators(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-multi-4"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file that names a generator and also lists generators")
+	renderspec := `generator: docker
+generators:
+  - generator: docker
+    parameters:
+      serviceName: 'hello'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-all.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-all.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("an appropriate error is returned")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.Errors))
+	require.Equal(t, "render spec must either specify generator and parameters or a list of generators, not both", actualResponse.Errors[0].Error())
+}
@@ -0,0 +1,324 @@
+package acceptance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/docs"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	api.NoopRenderObserver
+	events []string
+}
+
+func (o *recordingObserver) OnRenderStart(_ context.Context, progress api.RenderProgress) {
+	o.events = append(o.events, fmt.Sprintf("start %d", progress.TemplatesTotal))
+}
+
+func (o *recordingObserver) OnTemplateStart(_ context.Context, tplSpec api.TemplateSpec, progress api.RenderProgress) {
+	o.events = append(o.events, fmt.Sprintf("template %d/%d %s", progress.TemplatesStarted, progress.TemplatesTotal, tplSpec.RelativeSourcePath))
+}
+
+func (o *recordingObserver) OnFileRendered(_ context.Context, result api.FileResult, progress api.RenderProgress) {
+	o.events = append(o.events, fmt.Sprintf("rendered %s (%d)", result.RelativeFilePath, progress.FilesRendered))
+}
+
+func (o *recordingObserver) OnFileSkipped(_ context.Context, relativeFilePath string, progress api.RenderProgress) {
+	o.events = append(o.events, fmt.Sprintf("skipped %s (%d)", relativeFilePath, progress.FilesSkipped))
+}
+
+func (o *recordingObserver) OnError(_ context.Context, err error, progress api.RenderProgress) {
+	o.events = append(o.events, fmt.Sprintf("error %s (%d)", err.Error(), progress.Errors))
+}
+
+func (o *recordingObserver) OnRenderFinished(_ context.Context, response *api.Response, progress api.RenderProgress) {
+	o.events = append(o.events, fmt.Sprintf("finished %t %d/%d/%d", response.Success, progress.FilesRendered, progress.FilesSkipped, progress.Errors))
+}
+
+func TestRender_ShouldNotifyObserver(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-observer-1"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator items, which uses with_items and a condition")
+	renderspec := `generator: items
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-items.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with an observer")
+	observer := &recordingObserver{}
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-items.yaml",
+		Observer:       observer,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the observer is notified about all events in order")
+	require.True(t, actualResponse.Success)
+	expectedEvents := []string{
+		"start 1",
+		"template 1/1 item.txt.tmpl",
+		"rendered first.txt (1)",
+		"rendered second.txt (2)",
+		"rendered third.txt (3)",
+		"skipped fourth.txt (1)",
+		"finished true 3/1/0",
+	}
+	require.Equal(t, expectedEvents, observer.events)
+}
+
+func TestRender_ShouldNotifyObserverOfErrors(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/invalid-generator-specs"
+	targetdirpath := "../output/render-observer-2"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator items, where a template has syntax errors")
+	renderspec := `generator: items
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-items.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with an observer")
+	observer := &recordingObserver{}
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-items.yaml",
+		Observer:       observer,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the observer is notified about the error")
+	require.False(t, actualResponse.Success)
+	expectedEvents := []string{
+		"start 2",
+		"template 1/2 itemerror.txt.tmpl",
+		"error failed to parse template itemerror.txt.tmpl: template: itemerror.txt.tmpl:1: unexpected \"!\" in operand (1)",
+		"template 2/2 item.txt.tmpl",
+		"rendered first.txt (1)",
+		"rendered second.txt (2)",
+		"rendered third.txt (3)",
+		"finished false 3/0/1",
+	}
+	require.Equal(t, expectedEvents, observer.events)
+}
+
+func TestRender_ShouldNotifyObserverIfRenderSpecMissing(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory without render spec")
+	targetdirpath := "../output/render-observer-3"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.When("Render is invoked with an observer")
+	observer := &recordingObserver{}
+	request := &api.Request{
+		SourceBaseDir: "../resources/valid-generator-simple",
+		TargetBaseDir: targetdirpath,
+		Observer:      observer,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the observer is only notified that rendering has finished")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, []string{"finished false 0/0/0"}, observer.events)
+}
+
+type cancellingObserver struct {
+	api.NoopRenderObserver
+	cancel context.CancelFunc
+}
+
+func (o *cancellingObserver) OnFileRendered(_ context.Context, _ api.FileResult, _ api.RenderProgress) {
+	o.cancel()
+}
+
+func TestRender_ShouldStopWhenContextCancelled(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-observer-4"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator items, which renders several files")
+	renderspec := `generator: items
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-items.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with a context that is cancelled after the first file has been rendered")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-items.yaml",
+		Observer:       &cancellingObserver{cancel: cancel},
+	}
+	actualResponse := generatorlib.Render(ctx, request)
+
+	docs.Then("the remaining files are reported as cancelled and not written")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 2, len(actualResponse.Errors))
+	require.True(t, errors.Is(actualResponse.Errors[1], context.Canceled))
+	require.Equal(t, 4, len(actualResponse.RenderedFiles))
+	require.True(t, actualResponse.RenderedFiles[0].Success)
+	for _, cancelled := range actualResponse.RenderedFiles[1:3] {
+		require.False(t, cancelled.Success)
+		require.True(t, errors.Is(cancelled.Errors[0], context.Canceled))
+	}
+	require.Equal(t, "template #1 (item.txt.tmpl) for item #2 was not rendered: context canceled", actualResponse.RenderedFiles[1].Errors[0].Error())
+	require.Equal(t, api.FileSkipped, actualResponse.RenderedFiles[3].Action)
+	_, err := dir.ReadFile(context.TODO(), "first.txt")
+	require.Nil(t, err)
+	_, err = dir.ReadFile(context.TODO(), "second.txt")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldNotRenderAnythingIfContextAlreadyDone(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-observer-5"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator override")
+	renderspec := `generator: override
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-override.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with a context whose deadline has already passed")
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-override.yaml",
+	}
+	actualResponse := generatorlib.Render(ctx, request)
+
+	docs.Then("every template is reported as not rendered")
+	expectedResponse := &api.Response{
+		Success: false,
+		RenderedFiles: []api.FileResult{
+			{
+				RelativeFilePath: "config.txt",
+				Errors:           []error{fmt.Errorf("template #1 (override/config.txt.tmpl) was not rendered: %w", context.DeadlineExceeded)},
+			},
+			{
+				RelativeFilePath: "README.md",
+				Errors:           []error{fmt.Errorf("template #2 (override/README.md.tmpl) was not rendered: %w", context.DeadlineExceeded)},
+			},
+			{
+				RelativeFilePath: "config.txt",
+				Errors:           []error{fmt.Errorf("template #3 (override/custom-config.txt.tmpl) was not rendered: %w", context.DeadlineExceeded)},
+			},
+		},
+		Errors: []error{
+			errors.New("an error occurred during rendering, see individual files"),
+			fmt.Errorf("rendering was cancelled: %w", context.DeadlineExceeded),
+		},
+	}
+	require.Equal(t, expectedResponse, actualResponse)
+	_, err := dir.ReadFile(context.TODO(), "README.md")
+	require.NotNil(t, err)
+}
+
+func TestRender_ShouldNotifyObserverOfTemplatesWithoutFiles(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-observer-6"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator observed, where some with_files globs do not match anything")
+	renderspec := `generator: observed
+parameters: {}
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-observed.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with an observer, rendering files in parallel")
+	observer := &recordingObserver{}
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-observed.yaml",
+		Observer:       observer,
+		Parallelism:    4,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("every template is reported as started in order, right before its files are rendered")
+	require.True(t, actualResponse.Success)
+	expectedEvents := []string{
+		"start 3",
+		"template 1/3 {{ .file }}",
+		"template 2/3 item.txt.tmpl",
+		"rendered first.txt (1)",
+		"template 3/3 {{ .file }}",
+		"finished true 1/0/0",
+	}
+	require.Equal(t, expectedEvents, observer.events)
+}
+
+func TestRender_ShouldNotifyObserverOfOverriddenFiles(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-observer-7"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid render spec file for generator override, where a later template replaces an earlier one")
+	renderspec := `generator: override
+parameters:
+  serviceName: 'hello-service'
+  customConfig: 'true'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-override.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with an observer")
+	observer := &recordingObserver{}
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-override.yaml",
+		Observer:       observer,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("the replaced file is reported as skipped, and then as rendered by the later template")
+	require.True(t, actualResponse.Success)
+	expectedEvents := []string{
+		"start 3",
+		"template 1/3 override/config.txt.tmpl",
+		"skipped config.txt (1)",
+		"template 2/3 override/README.md.tmpl",
+		"rendered README.md (1)",
+		"template 3/3 override/custom-config.txt.tmpl",
+		"rendered config.txt (2)",
+		"finished true 2/1/0",
+	}
+	require.Equal(t, expectedEvents, observer.events)
+}
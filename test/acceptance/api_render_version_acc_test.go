@@ -0,0 +1,107 @@
+package acceptance
+
+import (
+	"context"
+	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/docs"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestRender_ShouldAcceptRenderSpecForCompatibleVersion(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-version-1"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file written for an older version of generator versioned with the same major version")
+	renderspec := `generator: versioned
+generator_version: 2.0.3
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-versioned.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with version checks enabled")
+	request := &api.Request{
+		SourceBaseDir:             sourcedirpath,
+		TargetBaseDir:             targetdirpath,
+		RenderSpecFile:            "generated-versioned.yaml",
+		FailOnIncompatibleVersion: true,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("rendering is successful without any warnings")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.RenderedFiles))
+	require.Nil(t, actualResponse.Warnings)
+}
+
+func TestRender_ShouldWarnAboutRenderSpecForIncompatibleVersion(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-version-2"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file written for an older major version of generator versioned")
+	renderspec := `generator: versioned
+generator_version: 1.4.0
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-versioned.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked")
+	request := &api.Request{
+		SourceBaseDir:  sourcedirpath,
+		TargetBaseDir:  targetdirpath,
+		RenderSpecFile: "generated-versioned.yaml",
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("rendering is successful, but the response contains a warning")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.RenderedFiles))
+	require.Equal(t, []string{"render spec was written for version 1.4.0 of generator versioned, which is incompatible with its current version 2.1.0"}, actualResponse.Warnings)
+}
+
+func TestRender_ShouldFailOnRenderSpecForIncompatibleVersionIfConfigured(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/render-version-3"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file written for an invalid version of generator versioned")
+	renderspec := `generator: versioned
+generator_version: 'one'
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-versioned.yaml", []byte(renderspec)))
+
+	docs.When("Render is invoked with version checks enabled")
+	request := &api.Request{
+		SourceBaseDir:             sourcedirpath,
+		TargetBaseDir:             targetdirpath,
+		RenderSpecFile:            "generated-versioned.yaml",
+		FailOnIncompatibleVersion: true,
+	}
+	actualResponse := generatorlib.Render(context.TODO(), request)
+
+	docs.Then("nothing is rendered and an appropriate error is returned")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 0, len(actualResponse.RenderedFiles))
+	require.Equal(t, 1, len(actualResponse.Errors))
+	require.Equal(t, "render spec was written for version 'one' of generator versioned, which is not a valid semantic version", actualResponse.Errors[0].Error())
+	_, err := os.Stat(targetdirpath + "/README.md")
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,139 @@
+package acceptance
+
+import (
+	"context"
+	generatorlib "github.com/StephanHCB/go-generator-lib"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/docs"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestUpgrade_ShouldMigrateRenderAndRemoveFilesNoLongerRendered(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/upgrade-1"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file written by an upgrade with version 2.0.0 of generator migrated, and the files it rendered")
+	renderspec := `generator: migrated
+generator_version: 2.0.0
+parameters:
+  serviceName: 'hello-service'
+  modulePath: 'github.com/StephanHCB/hello-service'
+rendered_files:
+  - README.md
+  - OLD.md
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-main.yaml", []byte(renderspec)))
+	require.Nil(t, dir.WriteFile(context.TODO(), "README.md", []byte("old readme\n")))
+	require.Nil(t, dir.WriteFile(context.TODO(), "OLD.md", []byte("no longer rendered\n")))
+
+	docs.When("Upgrade is invoked in a dry run")
+	request := &api.Request{
+		SourceBaseDir: sourcedirpath,
+		TargetBaseDir: targetdirpath,
+		DryRun:        true,
+	}
+	actualResponse := generatorlib.Upgrade(context.TODO(), request)
+
+	docs.Then("all changes are reported, but nothing is written or deleted")
+	requireUpgradeResponse := func(actualResponse *api.Response) {
+		require.True(t, actualResponse.Success)
+		require.Equal(t, 2, len(actualResponse.RenderedFiles))
+		require.Equal(t, "README.md", actualResponse.RenderedFiles[0].RelativeFilePath)
+		require.Equal(t, api.FileUpdated, actualResponse.RenderedFiles[0].Action)
+		require.Equal(t, api.FileResult{
+			Success:          true,
+			RelativeFilePath: "OLD.md",
+			Action:           api.FileDeleted,
+		}, actualResponse.RenderedFiles[1])
+		require.Equal(t, []string{"set parameter 'teamName' to 'platform' (migration to version 3.0.0)", "recorded generator version 3.0.0"}, actualResponse.Changes)
+		require.Equal(t, []string{"database"}, actualResponse.DefaultedParameters)
+		require.Nil(t, actualResponse.MissingParameters)
+	}
+	requireUpgradeResponse(actualResponse)
+	require.True(t, dir.FileExists(context.TODO(), "OLD.md"))
+	actual, err := dir.ReadFile(context.TODO(), "generated-main.yaml")
+	require.Nil(t, err)
+	require.Equal(t, renderspec, string(actual))
+
+	docs.When("Upgrade is invoked")
+	request.DryRun = false
+	actualResponse = generatorlib.Upgrade(context.TODO(), request)
+
+	docs.Then("the files are rendered, the file that is no longer rendered is deleted, and the render spec is updated")
+	requireUpgradeResponse(actualResponse)
+	require.False(t, dir.FileExists(context.TODO(), "OLD.md"))
+	readme, err := dir.ReadFile(context.TODO(), "README.md")
+	require.Nil(t, err)
+	require.Equal(t, "readme for hello-service\n", toUnix(string(readme)))
+	expectedContent := `generator: migrated
+generator_version: 3.0.0
+parameters:
+  serviceName: hello-service
+  modulePath: github.com/StephanHCB/hello-service
+  # The database settings of the service.
+  # optional
+  database:
+    name: app
+  # The name of the team that owns the service.
+  # required
+  teamName: platform
+rendered_files:
+- README.md
+`
+	actual, err = dir.ReadFile(context.TODO(), "generated-main.yaml")
+	require.Nil(t, err)
+	require.Equal(t, expectedContent, string(actual))
+
+	docs.When("Upgrade is invoked again")
+	actualResponse = generatorlib.Upgrade(context.TODO(), request)
+
+	docs.Then("nothing changes")
+	require.True(t, actualResponse.Success)
+	require.Equal(t, 1, len(actualResponse.RenderedFiles))
+	require.Equal(t, api.FileUnchanged, actualResponse.RenderedFiles[0].Action)
+	require.Equal(t, []string{}, actualResponse.Changes)
+	require.Equal(t, []string{}, actualResponse.DefaultedParameters)
+}
+
+func TestUpgrade_ShouldReportNewParametersThatRequireAValue(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/upgrade-2"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a render spec file for generator migrated that lacks parameters without default values")
+	renderspec := `generator: migrated
+generator_version: 3.0.0
+parameters:
+  serviceName: 'hello-service'
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), "generated-main.yaml", []byte(renderspec)))
+
+	docs.When("Upgrade is invoked")
+	request := &api.Request{
+		SourceBaseDir: sourcedirpath,
+		TargetBaseDir: targetdirpath,
+	}
+	actualResponse := generatorlib.Upgrade(context.TODO(), request)
+
+	docs.Then("the parameters that require a value are reported and nothing is rendered")
+	require.False(t, actualResponse.Success)
+	require.Equal(t, 0, len(actualResponse.RenderedFiles))
+	require.Equal(t, []string{"database"}, actualResponse.DefaultedParameters)
+	require.Equal(t, []string{"modulePath", "teamName"}, actualResponse.MissingParameters)
+	require.Equal(t, 1, len(actualResponse.Errors))
+	require.Equal(t, "new parameters require a value, nothing was rendered: modulePath, teamName", actualResponse.Errors[0].Error())
+	require.False(t, dir.FileExists(context.TODO(), "README.md"))
+	actual, err := dir.ReadFile(context.TODO(), "generated-main.yaml")
+	require.Nil(t, err)
+	require.Equal(t, renderspec, string(actual))
+}
@@ -33,9 +33,15 @@ func TestWriteRenderSpecWithDefaults_ShouldCreateMainSpec(t *testing.T) {
 	expectedFilename := "generated-main.yaml"
 	expectedContent := `generator: main
 parameters:
-  helloMessage: hello world
-  serviceName: ""
+  # The URL of the service repository, to be used in imports etc.
+  # optional
   serviceUrl: github.com/StephanHCB/temp
+  # The name of the service to be rendered.
+  # required, must match ^[a-z-]+$
+  serviceName: "" # TODO: required, please provide a value
+  # A message to be inserted in the code.
+  # optional
+  helloMessage: hello world
 `
 	expectedResponse := &api.Response{
 		Success: true,
@@ -82,7 +88,9 @@ parameters:
 	docs.Then("the render spec file is silently overwritten and the return value is as expected")
 	expectedContent := `generator: docker
 parameters:
-  serviceName: ""
+  # The name of the service to be rendered
+  # required, must match [a-zA-Z]+
+  serviceName: "" # TODO: required, please provide a value
 `
 	expectedResponse := &api.Response{
 		Success: true,
@@ -169,9 +177,15 @@ func TestWriteRenderSpecWithDefaults_ShouldCreateParsedDefaultsSpec(t *testing.T
 	expectedFilename := "generated-templatevars.yaml"
 	expectedContent := `generator: templatevars
 parameters:
-  helloMessage: heya
-  serviceName: ""
+  # The URL of the service repository, to be used in imports etc.
+  # optional
   serviceUrl: github.com/StephanHCB/temp
+  # The name of the service to be rendered.
+  # required, must match ^[a-z-]+$
+  serviceName: "" # TODO: required, please provide a value
+  # A message to be inserted in the code.
+  # optional
+  helloMessage: heya
 `
 	expectedResponse := &api.Response{
 		Success: true,
@@ -235,8 +249,12 @@ func TestWriteRenderSpecWithDefaults_ShouldCreateEmptyStringBothCases(t *testing
 	expectedFilename := "generated-emptydefaults.yaml"
 	expectedContent := `generator: emptydefaults
 parameters:
+  # A variable with an empty string as default.
+  # optional
   emptyStringDefault: ""
-  missingDefault: ""
+  # A variable with no default.
+  # required
+  missingDefault: "" # TODO: required, please provide a value
 `
 	expectedResponse := &api.Response{
 		Success: true,
@@ -253,3 +271,72 @@ parameters:
 	require.Equal(t, expectedContent, string(actual))
 	require.Equal(t, expectedResponse, actualResponse)
 }
+
+func TestWriteRenderSpecWithDefaults_ShouldRecordGeneratorVersion(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/write-render-spec-8"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid generator name of a generator that declares a version")
+	name := "versioned"
+
+	request := &api.Request{
+		SourceBaseDir: sourcedirpath,
+		TargetBaseDir: targetdirpath,
+	}
+	docs.When("WriteRenderSpecWithDefaults is invoked")
+	actualResponse := generatorlib.WriteRenderSpecWithDefaults(context.TODO(), request, name)
+
+	docs.Then("the spec file is written and records the generator version")
+	require.True(t, actualResponse.Success)
+	expectedContent := `generator: versioned
+generator_version: 2.1.0
+parameters:
+  # The name of the service to be rendered.
+  # optional
+  serviceName: hello-service
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	actual, err := dir.ReadFile(context.TODO(), "generated-versioned.yaml")
+	require.Nil(t, err)
+	require.Equal(t, expectedContent, string(actual))
+}
+
+func TestWriteRenderSpecWithDefaults_ShouldWriteParametersInDeclarationOrder(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/write-render-spec-9"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("the name of a generator whose variables are not declared in alphabetical order")
+	name := "presented"
+
+	request := &api.Request{
+		SourceBaseDir: sourcedirpath,
+		TargetBaseDir: targetdirpath,
+	}
+	docs.When("WriteRenderSpecWithDefaults is invoked")
+	actualResponse := generatorlib.WriteRenderSpecWithDefaults(context.TODO(), request, name)
+
+	docs.Then("the parameters are written in the order the variables are declared")
+	require.True(t, actualResponse.Success)
+	expectedContent := `generator: presented
+parameters:
+  # The name of the service to be rendered.
+  # required, must match ^[a-z-]+$
+  serviceName: "" # TODO: required, please provide a value
+  # Additional configuration for the service.
+  # optional
+  customConfig: ""
+  # The name of the database schema.
+  # optional
+  databaseName: app
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	actual, err := dir.ReadFile(context.TODO(), "generated-presented.yaml")
+	require.Nil(t, err)
+	require.Equal(t, expectedContent, string(actual))
+}
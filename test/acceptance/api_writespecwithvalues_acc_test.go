@@ -41,9 +41,15 @@ func TestWriteRenderSpecWithValues_ShouldCreateMainSpec(t *testing.T) {
 	expectedFilename := "generated-main.yaml"
 	expectedContent := `generator: main
 parameters:
-  helloMessage: hello nice world
-  serviceName: something-valid
+  # The URL of the service repository, to be used in imports etc.
+  # optional
   serviceUrl: github.com/StephanHCB/scratch
+  # The name of the service to be rendered.
+  # required, must match ^[a-z-]+$
+  serviceName: something-valid
+  # A message to be inserted in the code.
+  # optional
+  helloMessage: hello nice world
 `
 	expectedResponse := &api.Response{
 		Success: true,
@@ -88,9 +94,15 @@ func TestWriteRenderSpecWithValues_ShouldCreateNonstandardSpec(t *testing.T) {
 	expectedFilename := "generator-values.dat"
 	expectedContent := `generator: main
 parameters:
-  helloMessage: hello nice world
-  serviceName: something-valid
+  # The URL of the service repository, to be used in imports etc.
+  # optional
   serviceUrl: github.com/StephanHCB/scratch
+  # The name of the service to be rendered.
+  # required, must match ^[a-z-]+$
+  serviceName: something-valid
+  # A message to be inserted in the code.
+  # optional
+  helloMessage: hello nice world
 `
 	expectedResponse := &api.Response{
 		Success: true,
@@ -134,9 +146,15 @@ func TestWriteRenderSpecWithValues_ShouldCreateDefaultSpec(t *testing.T) {
 	expectedFilename := "generated-main.yaml"
 	expectedContent := `generator: main
 parameters:
-  helloMessage: hello nice world
-  serviceName: something-valid
+  # The URL of the service repository, to be used in imports etc.
+  # optional
   serviceUrl: github.com/StephanHCB/scratch
+  # The name of the service to be rendered.
+  # required, must match ^[a-z-]+$
+  serviceName: something-valid
+  # A message to be inserted in the code.
+  # optional
+  helloMessage: hello nice world
 `
 	expectedResponse := &api.Response{
 		Success: true,
@@ -187,6 +205,8 @@ parameters:
 	docs.Then("the render spec file is silently overwritten and the return value is as expected")
 	expectedContent := `generator: docker
 parameters:
+  # The name of the service to be rendered
+  # required, must match [a-zA-Z]+
   serviceName: docker-is-great
 `
 	expectedResponse := &api.Response{
@@ -204,6 +224,58 @@ parameters:
 	require.Equal(t, expectedResponse, actualResponse)
 }
 
+func TestWriteRenderSpecWithValues_ShouldKeepUserComments(t *testing.T) {
+	docs.Given("a valid generator source directory and a valid target directory")
+	sourcedirpath := "../resources/valid-generator-simple"
+	targetdirpath := "../output/write-render-spec-values-12"
+	require.Nil(t, os.RemoveAll(targetdirpath))
+	require.Nil(t, os.Mkdir(targetdirpath, 0755))
+
+	docs.Given("a valid generator name")
+	name := "main"
+
+	docs.Given("a render spec for this generator already exists, and the user has added comments to it")
+	expectedFilename := "generated-main.yaml"
+	originalContent := `# the hello service
+
+generator: main
+parameters:
+  # keep this in sync with the repository name
+  serviceName: "" # TODO: required, please provide a value
+  serviceUrl: github.com/StephanHCB/temp # our fork
+`
+	dir := targetdir.Instance(context.TODO(), targetdirpath)
+	require.Nil(t, dir.WriteFile(context.TODO(), expectedFilename, []byte(originalContent)))
+
+	docs.When("WriteRenderSpecWithValues is invoked")
+	request := &api.Request{
+		SourceBaseDir: sourcedirpath,
+		TargetBaseDir: targetdirpath,
+	}
+	parameters := map[string]interface{}{
+		"serviceName": "hello-service",
+		"serviceUrl":  "github.com/StephanHCB/temp",
+	}
+	actualResponse := generatorlib.WriteRenderSpecWithValues(context.TODO(), request, name, parameters)
+
+	docs.Then("the comments of the user are kept, outdated warnings are removed, and only the new parameter is described")
+	require.True(t, actualResponse.Success)
+	expectedContent := `# the hello service
+
+generator: main
+parameters:
+  serviceUrl: github.com/StephanHCB/temp # our fork
+  # keep this in sync with the repository name
+  serviceName: hello-service
+  # A message to be inserted in the code.
+  # optional
+  helloMessage: hello world
+`
+	actual, err := dir.ReadFile(context.TODO(), expectedFilename)
+	require.Nil(t, err)
+	require.Equal(t, expectedContent, string(actual))
+}
+
 func TestWriteRenderSpecWithValues_ShouldCreateMainSpec_StructuredDefaults(t *testing.T) {
 	docs.Given("a valid generator source directory and a valid target directory")
 	sourcedirpath := "../resources/valid-generator-structured"
@@ -227,13 +299,19 @@ func TestWriteRenderSpecWithValues_ShouldCreateMainSpec_StructuredDefaults(t *te
 	expectedFilename := "generated-main.yaml"
 	expectedContent := `generator: main
 parameters:
+  # A message to be inserted in the code.
+  # optional
   helloMessage: hello world
+  # A structured parameter that is a list at top level
+  # optional
   structureList:
   - one
   - two
   - three:
     - sub 1
     - sub 2
+  # A structured parameter that is a map at top level
+  # optional
   structureMap:
     commonName: European wildcat
     species: felis silvestris
@@ -281,11 +359,17 @@ func TestWriteRenderSpecWithValues_ShouldCreateMainSpec_StructuredValues(t *test
 	expectedFilename := "generated-main.yaml"
 	expectedContent := `generator: main
 parameters:
+  # A message to be inserted in the code.
+  # optional
   helloMessage: hello world
+  # A structured parameter that is a list at top level
+  # optional
   structureList:
   - eins
   - zwei
   - drei
+  # A structured parameter that is a map at top level
+  # optional
   structureMap:
     commonName: European wildcat
     species: felis silvestris
@@ -1,7 +1,28 @@
 package acceptance
 
-import "strings"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/StephanHCB/go-generator-lib/api"
+	"github.com/StephanHCB/go-generator-lib/internal/repository/targetdir"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
 
 func toUnix(t string) string {
 	return strings.ReplaceAll(t, "\r", "")
 }
+
+// withDetails fills in the size and hash of a rendered file, as read back from the target directory.
+//
+// The file is read back rather than hashing the expected contents, because line endings differ on windows.
+func withDetails(t *testing.T, dir *targetdir.TargetDirectory, result api.FileResult) api.FileResult {
+	contents, err := dir.ReadFile(context.TODO(), result.RelativeFilePath)
+	require.Nil(t, err)
+	result.Size = len(contents)
+	sum := sha256.Sum256(contents)
+	result.Hash = hex.EncodeToString(sum[:])
+	return result
+}